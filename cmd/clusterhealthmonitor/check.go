@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/Azure/cluster-health-monitor/pkg/status"
+	"k8s.io/klog/v2"
+)
+
+const (
+	outputJSON  = "json"
+	outputJUnit = "junit"
+
+	// exitCodeUnhealthy is returned by the check command if any checker did not report a healthy or skipped result.
+	exitCodeUnhealthy = 1
+	// exitCodeError is returned by the check command if the checkers could not be run at all, e.g. due to an invalid configuration.
+	exitCodeError = 2
+)
+
+// runCheck implements the check command. It runs every configured checker exactly once, prints the results to stdout in the requested
+// format and returns the exit code of the process.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	klog.InitFlags(fs)
+	once := fs.Bool("once", false, "Run every configured checker exactly once and exit. This is currently the only supported mode")
+	configPath := fs.String("config", defaultConfigPath, "Path to the configuration file")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file. If empty and --context is not set, the in-cluster config is used")
+	kubeContext := fs.String("context", "", "Kubeconfig context of the cluster to check. If empty, the current context is used")
+	output := fs.String("output", outputJSON, fmt.Sprintf("Output format, either %q or %q", outputJSON, outputJUnit))
+	if err := fs.Parse(args); err != nil {
+		return exitCodeError
+	}
+	defer klog.Flush()
+
+	if !*once {
+		klog.ErrorS(nil, "The check command requires --once")
+		return exitCodeError
+	}
+	if *output != outputJSON && *output != outputJUnit {
+		klog.ErrorS(nil, "Unsupported output format", "output", *output)
+		return exitCodeError
+	}
+
+	registerCheckers()
+	cfg, err := config.ParseFromFile(*configPath)
+	if err != nil {
+		klog.ErrorS(err, "Failed to parse config")
+		return exitCodeError
+	}
+	// The check command exits as soon as the checkers have run, before webhook notifications or events could be delivered, so webhooks
+	// are only notified and events only emitted by the monitor.
+	sinksCfg := *cfg
+	sinksCfg.ResultSinks = slices.DeleteFunc(slices.Clone(cfg.ResultSinks), func(t config.ResultSinkType) bool {
+		return t == config.ResultSinkWebhook || t == config.ResultSinkEvent
+	})
+	if err := checker.SetResultSinks(&sinksCfg); err != nil {
+		klog.ErrorS(err, "Failed to set result sinks")
+		return exitCodeError
+	}
+	k8sConfig, err := checker.LoadRESTConfig(*kubeconfig, *kubeContext)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get Kubernetes config")
+		return exitCodeError
+	}
+	cluster, err := checker.NewCluster("", k8sConfig)
+	if err != nil {
+		klog.ErrorS(err, "Failed to create Kubernetes client")
+		return exitCodeError
+	}
+
+	resp, durations, err := runCheckersOnce(context.Background(), cfg, cluster)
+	if err != nil {
+		klog.ErrorS(err, "Failed to run checkers")
+		return exitCodeError
+	}
+
+	switch *output {
+	case outputJSON:
+		err = writeJSON(os.Stdout, resp)
+	case outputJUnit:
+		err = writeJUnit(os.Stdout, resp, durations)
+	}
+	if err != nil {
+		klog.ErrorS(err, "Failed to write results")
+		return exitCodeError
+	}
+
+	for _, cs := range resp.Checkers {
+		if !isHealthy(cs) {
+			return exitCodeUnhealthy
+		}
+	}
+	return 0
+}
+
+// runCheckersOnce builds every configured checker and runs them concurrently, each bounded by its timeout. It returns the results and
+// the duration of each run keyed by checker name. Checkers skipped at build time are reported with the skipped status, and checkers that
+// panic are reported as unhealthy with the Panic error code.
+func runCheckersOnce(ctx context.Context, cfg *config.Config, cluster *checker.Cluster) (status.Response, map[string]time.Duration, error) {
+	var chks []checker.Checker
+	var skipped []status.CheckerStatus
+	timeouts := make(map[string]time.Duration, len(cfg.Checkers))
+	for _, chkCfg := range cfg.Checkers {
+		chk, configure, err := checker.Build(&chkCfg, cluster)
+		if configure != nil {
+			configure()
+		}
+		if errors.Is(err, checker.ErrSkipChecker) {
+			skipped = append(skipped, status.CheckerStatus{
+				Name:    chkCfg.Name,
+				Type:    string(chkCfg.Type),
+				Status:  string(checker.StatusSkipped),
+				Message: "checker does not apply to the cluster",
+			})
+			continue
+		}
+		if err != nil {
+			return status.Response{}, nil, fmt.Errorf("failed to build checker %q: %w", chkCfg.Name, err)
+		}
+		chks = append(chks, chk)
+		timeouts[chk.Name()] = chkCfg.Timeout
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	durations := make(map[string]time.Duration, len(chks))
+	panicked := make(map[string]bool)
+	for _, chk := range chks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runCtx, cancel := context.WithTimeout(checker.WithCluster(ctx, cluster.Name), timeouts[chk.Name()])
+			defer cancel()
+			runCtx = checker.WithRunStatus(runCtx)
+			start := time.Now()
+			recovered := runRecovered(runCtx, chk)
+			duration := time.Since(start)
+			checker.RecordRunDuration(runCtx, chk, duration)
+
+			mu.Lock()
+			defer mu.Unlock()
+			durations[chk.Name()] = duration
+			panicked[chk.Name()] = recovered
+		}()
+	}
+	wg.Wait()
+
+	resp := status.NewResponse(cluster.Name, chks)
+	for i, cs := range resp.Checkers {
+		if panicked[cs.Name] {
+			// A checker that panicked is broken rather than unable to tell the status, so it fails the check like an unhealthy checker.
+			resp.Checkers[i].Status = metrics.UnhealthyStatus
+			resp.Checkers[i].ErrorCode = metrics.PanicCode
+			continue
+		}
+		if cs.Status == "" && len(cs.Targets) == 0 {
+			resp.Checkers[i].Status = metrics.UnknownStatus
+			resp.Checkers[i].Message = "checker did not record a result"
+		}
+	}
+	resp.Checkers = append(resp.Checkers, skipped...)
+	return resp, durations, nil
+}
+
+// runRecovered runs a checker once and recovers from a panic in its Run, which is recorded as a result of the checker. It returns whether
+// the checker panicked. ctx must be the context returned by checker.WithRunStatus.
+func runRecovered(ctx context.Context, chk checker.Checker) (panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			klog.ErrorS(&checker.PanicError{Value: v}, "Recovered from panic in checker run", "name", chk.Name(), "type", string(chk.Type()),
+				"stack", string(debug.Stack()))
+			checker.RecordPanic(ctx, chk, v)
+			panicked = true
+		}
+	}()
+	chk.Run(ctx)
+	return false
+}
+
+// isHealthy returns whether a checker and all of its targets reported a healthy or skipped result.
+func isHealthy(cs status.CheckerStatus) bool {
+	if cs.Status != "" && !isHealthyStatus(cs.Status) {
+		return false
+	}
+	for _, ts := range cs.Targets {
+		if !isHealthyStatus(ts.Status) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHealthyStatus(s string) bool {
+	return s == string(checker.StatusHealthy) || s == string(checker.StatusSkipped)
+}
+
+func writeJSON(w io.Writer, resp status.Response) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(resp)
+}
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Type    string `xml:"type,attr,omitempty"`
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// writeJUnit writes the results as a JUnit XML report with one test case per checker, and one per pod for checkers that record per-pod
+// results. Unhealthy results are reported as failures, unknown results as errors.
+func writeJUnit(w io.Writer, resp status.Response, durations map[string]time.Duration) error {
+	suite := junitTestSuite{Name: "cluster-health-monitor"}
+	addCase := func(tc junitTestCase, s, code, message string) {
+		msg := &junitMessage{Type: code, Message: message}
+		switch s {
+		case string(checker.StatusHealthy):
+		case string(checker.StatusSkipped):
+			tc.Skipped = msg
+			suite.Skipped++
+		case string(checker.StatusUnhealthy):
+			tc.Failure = msg
+			suite.Failures++
+		default:
+			tc.Error = msg
+			suite.Errors++
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	for _, cs := range resp.Checkers {
+		var duration string
+		if d, ok := durations[cs.Name]; ok {
+			duration = fmt.Sprintf("%.3f", d.Seconds())
+		}
+		if cs.Status != "" {
+			addCase(junitTestCase{ClassName: cs.Type, Name: cs.Name, Time: duration}, cs.Status, cs.ErrorCode, cs.Message)
+		}
+		for _, ts := range cs.Targets {
+			addCase(junitTestCase{ClassName: cs.Type, Name: cs.Name + "/" + ts.Name}, ts.Status, ts.ErrorCode, ts.Message)
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitTestSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
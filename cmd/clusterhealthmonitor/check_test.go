@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/Azure/cluster-health-monitor/pkg/status"
+	. "github.com/onsi/gomega"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsHealthy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		status   status.CheckerStatus
+		expected bool
+	}{
+		{
+			name:     "healthy checker",
+			status:   status.CheckerStatus{Status: "Healthy"},
+			expected: true,
+		},
+		{
+			name:     "skipped checker",
+			status:   status.CheckerStatus{Status: "Skipped"},
+			expected: true,
+		},
+		{
+			name:     "unhealthy checker",
+			status:   status.CheckerStatus{Status: "Unhealthy"},
+			expected: false,
+		},
+		{
+			name:     "unknown checker",
+			status:   status.CheckerStatus{Status: "Unknown"},
+			expected: false,
+		},
+		{
+			name:     "healthy targets",
+			status:   status.CheckerStatus{Targets: []status.TargetStatus{{Status: "Healthy"}, {Status: "Healthy"}}},
+			expected: true,
+		},
+		{
+			name:     "unhealthy target",
+			status:   status.CheckerStatus{Targets: []status.TargetStatus{{Status: "Healthy"}, {Status: "Unhealthy"}}},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(isHealthy(tc.status)).To(Equal(tc.expected))
+		})
+	}
+}
+
+// panickingChecker is a checker whose runs panic.
+type panickingChecker struct {
+	name string
+}
+
+func (p *panickingChecker) Name() string             { return p.name }
+func (p *panickingChecker) Type() config.CheckerType { return panickingType }
+func (p *panickingChecker) Run(ctx context.Context)  { panic("boom") }
+
+const panickingType config.CheckerType = "Panicking"
+
+func TestRunCheckersOnce_Panic(t *testing.T) {
+	g := NewWithT(t)
+	checker.RegisterChecker(panickingType, func(cfg *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
+		return &panickingChecker{name: cfg.Name}, nil
+	})
+	chkCfg := config.CheckerConfig{Name: "panicking", Type: panickingType, Interval: time.Minute, Timeout: time.Second}
+	t.Cleanup(func() { checker.ClearChecker("", &chkCfg) })
+
+	resp, durations, err := runCheckersOnce(context.Background(), &config.Config{Checkers: []config.CheckerConfig{chkCfg}},
+		&checker.Cluster{KubeClient: k8sfake.NewClientset()})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(durations).To(HaveKey("panicking"))
+	g.Expect(resp.Checkers).To(HaveLen(1))
+	g.Expect(resp.Checkers[0].Status).To(Equal(metrics.UnhealthyStatus))
+	g.Expect(resp.Checkers[0].ErrorCode).To(Equal(metrics.PanicCode))
+	g.Expect(resp.Checkers[0].Message).To(ContainSubstring("boom"))
+	g.Expect(isHealthy(resp.Checkers[0])).To(BeFalse())
+}
+
+func TestWriteJUnit(t *testing.T) {
+	g := NewWithT(t)
+	resp := status.Response{
+		Checkers: []status.CheckerStatus{
+			{Name: "healthy", Type: "DNS", Status: "Healthy"},
+			{Name: "unhealthy", Type: "APIServer", Status: "Unhealthy", ErrorCode: "APIServerCreateError", Message: "failed to create"},
+			{Name: "unknown", Type: "MetricsServer", Status: "Unknown", Message: "run error"},
+			{Name: "skipped", Type: "DNS", Status: "Skipped"},
+			{Name: "perpod", Type: "DNS", Targets: []status.TargetStatus{
+				{Name: "coredns-a", Status: "Healthy"},
+				{Name: "coredns-b", Status: "Unhealthy", ErrorCode: "PodTimeout"},
+			}},
+		},
+	}
+
+	var buf bytes.Buffer
+	g.Expect(writeJUnit(&buf, resp, map[string]time.Duration{"healthy": 1500 * time.Millisecond})).To(Succeed())
+
+	var report junitTestSuites
+	g.Expect(xml.Unmarshal(buf.Bytes(), &report)).To(Succeed())
+	g.Expect(report.Suites).To(HaveLen(1))
+	suite := report.Suites[0]
+	g.Expect(suite.Tests).To(Equal(6))
+	g.Expect(suite.Failures).To(Equal(2))
+	g.Expect(suite.Errors).To(Equal(1))
+	g.Expect(suite.Skipped).To(Equal(1))
+
+	g.Expect(suite.TestCases[0].Name).To(Equal("healthy"))
+	g.Expect(suite.TestCases[0].Time).To(Equal("1.500"))
+	g.Expect(suite.TestCases[0].Failure).To(BeNil())
+	g.Expect(suite.TestCases[1].Failure).ToNot(BeNil())
+	g.Expect(suite.TestCases[1].Failure.Type).To(Equal("APIServerCreateError"))
+	g.Expect(suite.TestCases[2].Error).ToNot(BeNil())
+	g.Expect(suite.TestCases[3].Skipped).ToNot(BeNil())
+	g.Expect(suite.TestCases[4].Name).To(Equal("perpod/coredns-a"))
+	g.Expect(suite.TestCases[5].Name).To(Equal("perpod/coredns-b"))
+	g.Expect(suite.TestCases[5].Failure.Type).To(Equal("PodTimeout"))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+)
+
+// clusterScheduler is a cluster checked by the monitor, along with the scheduler that runs its checkers.
+type clusterScheduler struct {
+	cluster *checker.Cluster
+	sched   *scheduler.Scheduler
+}
+
+// loadClusters returns the clusters of the given contexts of the kubeconfig file, or of the default kubeconfig loading rules if kubeconfig
+// is empty. Each cluster is named after its context, which labels the metrics and results of its checkers.
+func loadClusters(kubeconfig string, contexts []string) ([]*checker.Cluster, error) {
+	seen := make(map[string]struct{}, len(contexts))
+	clusters := make([]*checker.Cluster, 0, len(contexts))
+	for _, kubeContext := range contexts {
+		if _, ok := seen[kubeContext]; ok {
+			return nil, fmt.Errorf("duplicate cluster context %q", kubeContext)
+		}
+		seen[kubeContext] = struct{}{}
+		restConfig, err := checker.LoadRESTConfig(kubeconfig, kubeContext)
+		if err != nil {
+			return nil, err
+		}
+		cluster, err := checker.NewCluster(kubeContext, restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cluster %q: %w", kubeContext, err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+// ofCluster returns a suffix that names the cluster in error messages, or an empty string if the monitor checks a single cluster.
+func ofCluster(cluster *checker.Cluster) string {
+	if cluster.Name == "" {
+		return ""
+	}
+	return fmt.Sprintf(" of cluster %q", cluster.Name)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: a
+  cluster:
+    server: https://a.example.com
+- name: b
+  cluster:
+    server: https://b.example.com
+users:
+- name: user
+  user:
+    token: token
+contexts:
+- name: a
+  context:
+    cluster: a
+    user: user
+- name: b
+  context:
+    cluster: b
+    user: user
+current-context: a
+`
+
+func TestLoadClusters(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	g.Expect(os.WriteFile(path, []byte(testKubeconfig), 0o600)).To(Succeed())
+
+	clusters, err := loadClusters(path, []string{"b", "a"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(clusters).To(HaveLen(2))
+	g.Expect(clusters[0].Name).To(Equal("b"))
+	g.Expect(clusters[0].RESTConfig.Host).To(Equal("https://b.example.com"))
+	g.Expect(clusters[0].KubeClient).NotTo(BeNil())
+	g.Expect(clusters[1].Name).To(Equal("a"))
+	g.Expect(clusters[1].RESTConfig.Host).To(Equal("https://a.example.com"))
+
+	_, err = loadClusters(path, []string{"a", "a"})
+	g.Expect(err).To(MatchError(ContainSubstring("duplicate cluster context")))
+	_, err = loadClusters(path, []string{"missing"})
+	g.Expect(err).To(HaveOccurred())
+}
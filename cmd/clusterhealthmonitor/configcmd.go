@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// exitCodeInvalidConfig is returned by the config validate command if the configuration is invalid.
+	exitCodeInvalidConfig = 1
+
+	configUsage = `Usage:
+  clusterhealthmonitor config validate <file>  Validate a configuration file and print all errors
+  clusterhealthmonitor config schema           Print the JSON Schema of the configuration file`
+)
+
+// runConfig implements the config command, which validates configuration files and prints the JSON Schema of the configuration file so
+// that configurations can be checked in editors and CI before they are rolled out. It returns the exit code of the process.
+func runConfig(args []string) int {
+	defer klog.Flush()
+	switch {
+	case len(args) == 2 && args[0] == "validate":
+		return validateConfigFile(os.Stdout, args[1])
+	case len(args) == 1 && args[0] == "schema":
+		return writeConfigSchema(os.Stdout)
+	default:
+		fmt.Fprintln(os.Stderr, configUsage)
+		return exitCodeError
+	}
+}
+
+// validateConfigFile strictly validates the configuration file at path and prints every error to w.
+func validateConfigFile(w io.Writer, path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		klog.ErrorS(err, "Failed to read config file", "path", path)
+		return exitCodeError
+	}
+	errs, warnings := config.ValidateStrict(data)
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "%s: warning: %s\n", path, warning)
+	}
+	for _, err := range errs {
+		fmt.Fprintf(w, "%s: %s\n", path, err)
+	}
+	if len(errs) > 0 {
+		fmt.Fprintf(w, "%s: %d error(s) found\n", path, len(errs))
+		return exitCodeInvalidConfig
+	}
+	fmt.Fprintf(w, "%s: config is valid\n", path)
+	return 0
+}
+
+// writeConfigSchema prints the JSON Schema of the configuration file to w.
+func writeConfigSchema(w io.Writer) int {
+	schema, err := config.JSONSchema()
+	if err != nil {
+		klog.ErrorS(err, "Failed to generate config schema")
+		return exitCodeError
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", schema); err != nil {
+		klog.ErrorS(err, "Failed to write config schema")
+		return exitCodeError
+	}
+	return 0
+}
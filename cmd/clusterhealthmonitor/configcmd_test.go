@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestValidateConfigFile(t *testing.T) {
+	testCases := []struct {
+		name           string
+		content        string
+		expectedCode   int
+		expectedOutput []string
+		missingFile    bool
+	}{
+		{
+			name: "valid config",
+			content: `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 5s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+`,
+			expectedOutput: []string{"config is valid"},
+		},
+		{
+			name: "invalid config",
+			content: `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 15s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+      port: 53
+`,
+			expectedCode:   exitCodeInvalidConfig,
+			expectedOutput: []string{`warning: checker "dns1": timeout 15s is greater`, "field port not found", "1 error(s) found"},
+		},
+		{
+			name: "config with warnings",
+			content: `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 15s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+`,
+			expectedOutput: []string{`warning: checker "dns1": timeout 15s is greater than interval 10s`, "config is valid"},
+		},
+		{
+			name:         "missing file",
+			missingFile:  true,
+			expectedCode: exitCodeError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if !tc.missingFile {
+				g.Expect(os.WriteFile(path, []byte(tc.content), 0o600)).To(Succeed())
+			}
+			var out bytes.Buffer
+			g.Expect(validateConfigFile(&out, path)).To(Equal(tc.expectedCode))
+			for _, expected := range tc.expectedOutput {
+				g.Expect(out.String()).To(ContainSubstring(expected))
+			}
+		})
+	}
+}
+
+func TestWriteConfigSchema(t *testing.T) {
+	g := NewWithT(t)
+	var out bytes.Buffer
+	g.Expect(writeConfigSchema(&out)).To(Equal(0))
+	g.Expect(json.Valid(out.Bytes())).To(BeTrue())
+	g.Expect(out.String()).To(ContainSubstring(`"$schema"`))
+}
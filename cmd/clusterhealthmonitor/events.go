@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+const (
+	eventComponent               = "cluster-health-monitor"
+	defaultEventObject           = "Deployment/kube-system/cluster-health-monitor"
+	defaultEventObjectAPIVersion = "apps/v1"
+)
+
+// parseEventObject parses a reference to the object events are emitted against, in the form <kind>/<namespace>/<name>.
+func parseEventObject(object, apiVersion string) (*corev1.ObjectReference, error) {
+	parts := strings.Split(object, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid event object %q, expected <kind>/<namespace>/<name>", object)
+	}
+	return &corev1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       parts[0],
+		Namespace:  parts[1],
+		Name:       parts[2],
+	}, nil
+}
+
+// newResultTracker creates the result sink of config.ResultSinkEvent, which emits checker status transitions as events against object. The
+// returned function stops the event broadcaster.
+func newResultTracker(kubeClient kubernetes.Interface, object *corev1.ObjectReference) (*checker.ResultTracker, func()) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartStructuredLogging(3)
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events(object.Namespace)})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: eventComponent})
+	klog.InfoS("Emitting checker events", "kind", object.Kind, "namespace", object.Namespace, "name", object.Name)
+	return checker.NewResultTracker(recorder, object), broadcaster.Shutdown
+}
+
+// nopSink is a result sink that does not record anything.
+type nopSink struct{}
+
+func (nopSink) Record(checker.ResultRecord) {}
+
+// buildDisabledEventSink builds the result sink of config.ResultSinkEvent when events are disabled. Configurations that list the Event
+// sink stay valid, so that they can be shared by monitors with and without events, and the sink does not record anything.
+func buildDisabledEventSink(*config.Config) (checker.ResultSink, error) {
+	klog.ErrorS(nil, "The Event result sink is configured but events are disabled, set --event-object to emit events")
+	return nopSink{}, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseEventObject(t *testing.T) {
+	testCases := []struct {
+		name        string
+		object      string
+		expected    *corev1.ObjectReference
+		expectedErr bool
+	}{
+		{
+			name:   "valid object",
+			object: "Deployment/kube-system/cluster-health-monitor",
+			expected: &corev1.ObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "kube-system",
+				Name:       "cluster-health-monitor",
+			},
+		},
+		{
+			name:        "missing namespace",
+			object:      "Deployment/cluster-health-monitor",
+			expectedErr: true,
+		},
+		{
+			name:        "empty name",
+			object:      "Deployment/kube-system/",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			object, err := parseEventObject(tc.object, "apps/v1")
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(object).To(Equal(tc.expected))
+		})
+	}
+}
+
+func TestBuildDisabledEventSink(t *testing.T) {
+	g := NewWithT(t)
+	checker.RegisterResultSink(config.ResultSinkEvent, buildDisabledEventSink)
+	t.Cleanup(func() { _ = checker.SetResultSinks(&config.Config{}) })
+
+	g.Expect(checker.SetResultSinks(&config.Config{ResultSinks: []config.ResultSinkType{config.ResultSinkEvent}})).To(Succeed())
+}
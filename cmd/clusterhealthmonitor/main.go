@@ -5,9 +5,14 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
+	"github.com/Azure/cluster-health-monitor/pkg/admin"
 	"github.com/Azure/cluster-health-monitor/pkg/checker"
 	"github.com/Azure/cluster-health-monitor/pkg/checker/apiserver"
 	"github.com/Azure/cluster-health-monitor/pkg/checker/azurepolicy"
@@ -15,15 +20,42 @@ import (
 	"github.com/Azure/cluster-health-monitor/pkg/checker/metricsserver"
 	"github.com/Azure/cluster-health-monitor/pkg/checker/podstartup"
 	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/healthcheck"
+	"github.com/Azure/cluster-health-monitor/pkg/leader"
 	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/Azure/cluster-health-monitor/pkg/reaper"
 	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
+	"github.com/Azure/cluster-health-monitor/pkg/status"
+	"github.com/Azure/cluster-health-monitor/pkg/tracing"
+	"github.com/Azure/cluster-health-monitor/pkg/webhook"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
 )
 
 const (
-	defaultConfigPath = "/etc/cluster-health-monitor/config.yaml"
+	defaultConfigPath           = "/etc/cluster-health-monitor/config.yaml"
+	defaultConfigReloadInterval = 10 * time.Second
+	defaultStartStagger         = 10 * time.Second
+	// defaultHealthCheckStatusSyncInterval is how often the latest results are written to the status of HealthCheck resources.
+	defaultHealthCheckStatusSyncInterval = 30 * time.Second
+
+	defaultLeaseName      = "cluster-health-monitor"
+	defaultLeaseNamespace = "kube-system"
+	defaultLeaseDuration  = 15 * time.Second
+	defaultRenewDeadline  = 10 * time.Second
+	defaultRetryPeriod    = 2 * time.Second
+
+	defaultShutdownGracePeriod  = 30 * time.Second
+	defaultShutdownSweepTimeout = 30 * time.Second
+
+	defaultReaperInterval = 10 * time.Minute
+	defaultReaperMinAge   = time.Hour
+	// defaultReaperLabelKeys are the label keys of the PodStartup and APIServer checkers of the base configuration.
+	defaultReaperLabelKeys = "kubernetes.azure.com/cluster-health-monitor-checker-synthetic,kubernetes.azure.com/cluster-health-monitor-checker-apiserver"
+
+	defaultTracingSamplingRatio = 1.0
+	// tracingShutdownTimeout is how long pending spans are flushed for on shutdown.
+	tracingShutdownTimeout = 5 * time.Second
 )
 
 func init() {
@@ -37,30 +69,181 @@ func logErrorAndExit(err error, message string) {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfig(os.Args[2:]))
+	}
+
 	configPath := flag.String("config", defaultConfigPath, "Path to the configuration file")
+	kubeconfig := flag.String("kubeconfig", "",
+		"Path to the kubeconfig file used to connect to the cluster, e.g. to run the monitor outside of the cluster. If empty and --context is not set, the in-cluster config is used")
+	kubeContext := flag.String("context", "", "Kubeconfig context of the cluster to connect to. If empty, the current context is used")
+	clusterContexts := flag.String("clusters", "",
+		"Comma-separated kubeconfig contexts of the clusters to check with the configured checkers. Metrics and results are labeled with the context of their cluster. The cluster of --kubeconfig and --context still holds the leader election Lease and the events. Not supported with --enable-healthcheck-crd or --enable-admin-api")
+	configReloadInterval := flag.Duration("config-reload-interval", defaultConfigReloadInterval,
+		"How often to check the configuration file for changes. Set to 0 to disable live configuration reload")
+	startStagger := flag.Duration("start-stagger", defaultStartStagger,
+		"Window across which the first runs of the configured checkers are spread on startup. Set to 0 to start all checkers at once")
+	enableHealthCheckCRD := flag.Bool("enable-healthcheck-crd", false,
+		"Schedule the checkers of HealthCheck custom resources in addition to the checkers of the configuration file")
+	leaderElect := flag.Bool("leader-elect", false,
+		"Run the checkers that create resources only on the replica that holds the leader election Lease, so that the monitor can run with multiple replicas")
+	leaderElectLeaseName := flag.String("leader-elect-lease-name", defaultLeaseName, "Name of the leader election Lease")
+	leaderElectLeaseNamespace := flag.String("leader-elect-lease-namespace", defaultLeaseNamespace, "Namespace of the leader election Lease")
+	leaderElectIdentity := flag.String("leader-elect-identity", "", "Identity of this replica in the leader election Lease. Defaults to the hostname")
+	leaderElectLeaseDuration := flag.Duration("leader-elect-lease-duration", defaultLeaseDuration,
+		"How long other replicas wait before taking over a Lease that is not renewed")
+	leaderElectRenewDeadline := flag.Duration("leader-elect-renew-deadline", defaultRenewDeadline,
+		"How long the leader tries to renew the Lease before giving up leadership")
+	leaderElectRetryPeriod := flag.Duration("leader-elect-retry-period", defaultRetryPeriod,
+		"How long replicas wait between attempts to acquire or renew the Lease")
+	readOnlyCheckersOnAllReplicas := flag.Bool("read-only-checkers-on-all-replicas", false,
+		"With leader election, run read-only checkers (DNS, MetricsServer) on every replica instead of only on the leader")
+	eventObject := flag.String("event-object", defaultEventObject,
+		"Object to emit checker status change events against, in the form <kind>/<namespace>/<name>. Set to empty to disable events")
+	eventObjectAPIVersion := flag.String("event-object-api-version", defaultEventObjectAPIVersion, "API version of the event object")
+	tracingEndpoint := flag.String("tracing-endpoint", "",
+		"URL of the OTLP/HTTP traces endpoint that checker run traces are exported to, e.g. http://otel-collector:4318/v1/traces. Set to empty to disable tracing")
+	tracingSamplingRatio := flag.Float64("tracing-sampling-ratio", defaultTracingSamplingRatio, "Fraction of checker runs that are traced, between 0 and 1")
+	shutdownGracePeriod := flag.Duration("shutdown-grace-period", defaultShutdownGracePeriod,
+		"How long checker runs in progress are allowed to complete on shutdown before they are aborted")
+	shutdownSweepTimeout := flag.Duration("shutdown-sweep-timeout", defaultShutdownSweepTimeout,
+		"How long the final sweep of the resources created by the checkers may take on shutdown")
+	metricsBindAddress := flag.String("metrics-bind-address", metrics.DefaultBindAddress, "Address the metrics server listens on")
+	metricsPort := flag.Int("metrics-port", metrics.DefaultPort, "Port the metrics server listens on")
+	metricsTLSCertFile := flag.String("metrics-tls-cert-file", "",
+		"Path to the PEM-encoded TLS certificate of the metrics server. If set with --metrics-tls-key-file, the metrics server serves HTTPS and reloads the certificate when the file changes")
+	metricsTLSKeyFile := flag.String("metrics-tls-key-file", "", "Path to the PEM-encoded TLS private key of the metrics server")
+	metricsAuth := flag.Bool("metrics-auth", false,
+		"Authenticate requests to the metrics server with TokenReview and authorize them with SubjectAccessReview, e.g. scraping requires get on the /metrics non-resource URL. /healthz and /readyz stay unauthenticated")
+	enableAdminAPI := flag.Bool("enable-admin-api", false,
+		"Serve the admin API, which lists, triggers, pauses and resumes checkers, on the metrics server. Requires --metrics-auth")
+	ownerDeployment := flag.String("owner-deployment", defaultOwnerDeployment,
+		"Deployment of the monitor, in the form <namespace>/<name>, set as owner of the objects that checkers create in its namespace, so that they are garbage collected when the monitor is uninstalled. Set to empty to disable")
+	ownerAnchor := flag.String("owner-anchor", defaultOwnerAnchor,
+		"Name of the ClusterRole set as owner of the cluster-scoped objects that checkers create, and of the objects they create outside the namespace of --owner-deployment. Set to empty to disable")
+	reaperInterval := flag.Duration("reaper-interval", defaultReaperInterval,
+		"How often the objects left behind by checkers that were renamed or removed from the configuration are deleted. Set to 0 to disable the reaper")
+	reaperMinAge := flag.Duration("reaper-min-age", defaultReaperMinAge, "Minimum age of the orphaned checker objects deleted by the reaper")
+	reaperLabelKeys := flag.String("reaper-label-keys", defaultReaperLabelKeys,
+		"Comma-separated label keys of the objects created by checkers that the reaper looks for, in addition to the label keys of the configured checkers")
 	flag.Parse()
 	defer klog.Flush()
+	if *enableAdminAPI && !*metricsAuth {
+		logErrorAndExit(nil, "The admin API requires --metrics-auth")
+	}
+	contexts := parseList(*clusterContexts)
+	if len(contexts) > 0 && (*enableHealthCheckCRD || *enableAdminAPI) {
+		logErrorAndExit(nil, "--clusters is not supported with --enable-healthcheck-crd or --enable-admin-api")
+	}
 
 	klog.InfoS("Started Cluster Health Monitor")
 	registerCheckers()
+	webhook.Register()
 
 	// Wait for interrupt signal to gracefully shutdown.
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	// Run the prometheus metrics server.
-	m, err := metrics.NewServer(9800)
+	// Export the traces of checker runs.
+	if *tracingEndpoint != "" {
+		shutdown, err := tracing.Setup(ctx, tracing.Config{Endpoint: *tracingEndpoint, SamplingRatio: *tracingSamplingRatio})
+		if err != nil {
+			logErrorAndExit(err, "Failed to set up tracing")
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+			defer cancel()
+			if err := shutdown(shutdownCtx); err != nil {
+				klog.ErrorS(err, "Failed to shut down tracing")
+			}
+		}()
+	}
+
+	// Connect to the cluster the monitor runs in, or to the cluster of the kubeconfig. It holds the leader election Lease and the events,
+	// and it is the cluster that is checked unless --clusters is set.
+	k8sConfig, err := checker.LoadRESTConfig(*kubeconfig, *kubeContext)
+	if err != nil {
+		logErrorAndExit(err, "Failed to get Kubernetes config")
+	}
+	home, err := checker.NewCluster("", k8sConfig)
+	if err != nil {
+		logErrorAndExit(err, "Failed to create Kubernetes client")
+	}
+	kubeClient := home.KubeClient
+	clusters := []*checker.Cluster{home}
+	if len(contexts) > 0 {
+		if clusters, err = loadClusters(*kubeconfig, contexts); err != nil {
+			logErrorAndExit(err, "Failed to load clusters")
+		}
+		klog.InfoS("Checking several clusters", "clusters", contexts)
+	}
+
+	// Run the prometheus metrics server. The schedulers are set once the checkers are built, the health, readiness and status endpoints
+	// report on them from then on.
+	var scheds atomic.Pointer[[]clusterScheduler]
+	metricsOpts := metrics.Options{
+		BindAddress: *metricsBindAddress,
+		Port:        *metricsPort,
+		TLSCertFile: *metricsTLSCertFile,
+		TLSKeyFile:  *metricsTLSKeyFile,
+	}
+	if *metricsAuth {
+		metricsOpts.AuthClient = kubeClient
+	}
+	m, err := metrics.NewServer(metricsOpts)
 	if err != nil {
 		logErrorAndExit(err, "Failed to create metrics server")
 	}
+	m.AddHealthCheck("scheduler", func() error {
+		if css := scheds.Load(); css != nil {
+			for _, cs := range *css {
+				if err := cs.sched.Healthy(); err != nil {
+					return fmt.Errorf("scheduler%s: %w", ofCluster(cs.cluster), err)
+				}
+			}
+		}
+		return nil
+	})
+	m.AddReadyCheck("checkers", func() error {
+		if scheds.Load() == nil {
+			return errors.New("checkers have not been built")
+		}
+		return nil
+	})
+	m.Handle(status.Path, status.NewHandler(func() []*scheduler.Scheduler {
+		var schedulers []*scheduler.Scheduler
+		if css := scheds.Load(); css != nil {
+			for _, cs := range *css {
+				schedulers = append(schedulers, cs.sched)
+			}
+		}
+		return schedulers
+	}))
+	if *enableAdminAPI {
+		// The admin API is only enabled with a single cluster.
+		m.Handle(admin.Path+"/", admin.NewHandler(func() *scheduler.Scheduler {
+			if css := scheds.Load(); css != nil {
+				return (*css)[0].sched
+			}
+			return nil
+		}))
+	}
+	metricsCtx, stopMetrics := context.WithCancel(context.Background())
+	defer stopMetrics()
+	metricsDone := make(chan struct{})
 	go func() {
-		if err := m.Run(ctx); err != nil {
+		defer close(metricsDone)
+		if err := m.Run(metricsCtx); err != nil && !errors.Is(err, context.Canceled) {
 			logErrorAndExit(err, "Metrics server error")
 		}
 	}()
 
 	// Parse the configuration file.
-	cfg, err := config.ParseFromFile(*configPath)
+	watcher := config.NewFileWatcher(*configPath, *configReloadInterval)
+	cfg, err := watcher.Load()
 	if err != nil {
 		logErrorAndExit(err, "Failed to parse config")
 	}
@@ -68,56 +251,198 @@ func main() {
 		"path", *configPath,
 		"numCheckers", len(cfg.Checkers))
 
-	// Create Kubernetes client.
-	k8sConfig, err := rest.InClusterConfig()
-	if err != nil {
-		logErrorAndExit(err, "Failed to get in-cluster config")
+	// Emit events when checker statuses change. The same tracker is used whenever the sinks are rebuilt, so that it keeps the statuses it
+	// observed. If events are disabled, the Event result sink does not record anything.
+	checker.RegisterResultSink(config.ResultSinkEvent, buildDisabledEventSink)
+	if *eventObject != "" {
+		object, err := parseEventObject(*eventObject, *eventObjectAPIVersion)
+		if err != nil {
+			logErrorAndExit(err, "Failed to parse event object")
+		}
+		tracker, stop := newResultTracker(kubeClient, object)
+		defer stop()
+		checker.RegisterResultSink(config.ResultSinkEvent, func(*config.Config) (checker.ResultSink, error) {
+			return tracker, nil
+		})
 	}
-	kubeClient, err := kubernetes.NewForConfig(k8sConfig)
-	if err != nil {
-		logErrorAndExit(err, "Failed to create Kubernetes client")
+	if err := checker.SetResultSinks(cfg); err != nil {
+		logErrorAndExit(err, "Failed to set result sinks")
 	}
 
-	// Build the checker schedule from the configuration.
-	cs, err := buildCheckerSchedule(cfg, kubeClient)
-	if err != nil {
-		logErrorAndExit(err, "Failed to build checker schedule")
+	// Set owner references on the objects created by checkers, so that the garbage collector deletes them when the monitor is uninstalled.
+	// The owners are in the cluster of the monitor, so the objects created in the clusters of --clusters are not owned by them: the garbage
+	// collector of another cluster would delete the objects right away since it cannot find their owners.
+	if len(contexts) == 0 {
+		owners, err := resolveOwners(ctx, kubeClient, *ownerDeployment, *ownerAnchor)
+		if err != nil {
+			logErrorAndExit(err, "Failed to resolve owners of checker objects")
+		}
+		checker.SetOwners(owners)
 	}
-	klog.InfoS("Built checker schedule", "numSchedules", len(cs))
 
-	// Run the scheduler.
-	sched := scheduler.NewScheduler(cs)
-	go func() {
-		if err := sched.Start(ctx); err != nil {
-			logErrorAndExit(err, "Scheduler error")
+	// Build the checker schedule of every cluster from the configuration.
+	css := make([]clusterScheduler, 0, len(clusters))
+	for _, cluster := range clusters {
+		cs, err := buildCheckerSchedule(cfg, cluster)
+		if err != nil {
+			logErrorAndExit(err, "Failed to build checker schedule")
 		}
-	}()
-	klog.InfoS("Scheduler started")
+		klog.InfoS("Built checker schedule", "cluster", cluster.Name, "numSchedules", len(cs))
+		s := scheduler.NewScheduler(cs)
+		s.SetCluster(cluster.Name)
+		s.SetStartStagger(*startStagger)
+		css = append(css, clusterScheduler{cluster: cluster, sched: s})
+	}
+
+	// Run the schedulers. With leader election, only the leader runs checkers that create resources. The Lease is held until the resources
+	// of the checkers have been swept on shutdown, so that no other replica creates resources in the meantime.
+	var isLeader func() bool
+	if *leaderElect {
+		identity := *leaderElectIdentity
+		if identity == "" {
+			if identity, err = os.Hostname(); err != nil {
+				logErrorAndExit(err, "Failed to get hostname for leader election identity")
+			}
+		}
+		elector := leader.NewElector(kubeClient, leader.Config{
+			LeaseName:      *leaderElectLeaseName,
+			LeaseNamespace: *leaderElectLeaseNamespace,
+			Identity:       identity,
+			LeaseDuration:  *leaderElectLeaseDuration,
+			RenewDeadline:  *leaderElectRenewDeadline,
+			RetryPeriod:    *leaderElectRetryPeriod,
+		})
+		isLeader = elector.IsLeader
+		for _, cs := range css {
+			cs.sched.SetRunCondition(func(chk checker.Checker) bool {
+				return elector.IsLeader() || *readOnlyCheckersOnAllReplicas && checker.IsReadOnly(chk)
+			})
+		}
+		electionCtx, stopElection := context.WithCancel(context.Background())
+		electionDone := make(chan struct{})
+		go func() {
+			defer close(electionDone)
+			if err := elector.Run(electionCtx); err != nil && !errors.Is(err, context.Canceled) {
+				logErrorAndExit(err, "Leader election error")
+			}
+		}()
+		defer func() {
+			stopElection()
+			<-electionDone
+		}()
+	} else {
+		metrics.LeaderGauge.Set(1)
+	}
+	scheds.Store(&css)
+	// The schedulers are stopped explicitly on shutdown rather than with ctx, so that the runs in progress can complete.
+	for _, cs := range css {
+		go func() {
+			if err := cs.sched.Start(context.Background()); err != nil {
+				logErrorAndExit(err, "Scheduler error")
+			}
+		}()
+	}
+	klog.InfoS("Scheduler started", "numClusters", len(css))
 
-	<-ctx.Done()
+	// Watch the configuration file and apply changes to the running schedulers.
+	if *configReloadInterval > 0 {
+		reloader := newConfigReloader(cfg, css)
+		go func() {
+			if err := watcher.Run(ctx, reloader.apply); err != nil && !errors.Is(err, context.Canceled) {
+				logErrorAndExit(err, "Config watcher error")
+			}
+		}()
+	}
+
+	// Reconcile HealthCheck custom resources into checkers. HealthChecks are only supported with a single cluster.
+	if *enableHealthCheckCRD {
+		dynamicClient, err := dynamic.NewForConfig(k8sConfig)
+		if err != nil {
+			logErrorAndExit(err, "Failed to create dynamic client")
+		}
+		controller, err := healthcheck.NewController(dynamicClient, home, css[0].sched, defaultHealthCheckStatusSyncInterval)
+		if err != nil {
+			logErrorAndExit(err, "Failed to create HealthCheck controller")
+		}
+		if isLeader != nil {
+			controller.SetIsLeader(isLeader)
+		}
+		go func() {
+			if err := controller.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logErrorAndExit(err, "HealthCheck controller error")
+			}
+		}()
+	}
+
+	// Delete the objects left behind by checkers that were renamed or removed in every cluster. With leader election, only the leader
+	// deletes them.
+	if *reaperInterval > 0 {
+		for _, cs := range css {
+			dynamicClient, err := dynamic.NewForConfig(cs.cluster.RESTConfig)
+			if err != nil {
+				logErrorAndExit(err, "Failed to create dynamic client")
+			}
+			r := reaper.New(cs.cluster.KubeClient, dynamicClient, cs.sched.Checkers, reaper.Config{
+				Cluster:   cs.cluster.Name,
+				Interval:  *reaperInterval,
+				MinAge:    *reaperMinAge,
+				LabelKeys: parseList(*reaperLabelKeys),
+			})
+			if isLeader != nil {
+				r.SetIsLeader(isLeader)
+			}
+			go func() {
+				if err := r.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+					logErrorAndExit(err, "Reaper error")
+				}
+			}()
+		}
+	}
+
+	waitForShutdown(ctx, css, *shutdownGracePeriod, *shutdownSweepTimeout)
+	// The metrics server is stopped last, so that /metrics and the health endpoints are served while the checkers are drained and swept.
+	stopMetrics()
+	<-metricsDone
 	klog.InfoS("Stopped Cluster Health Monitor due to context cancel")
 }
 
-func buildCheckerSchedule(cfg *config.Config, kubeClient kubernetes.Interface) ([]scheduler.CheckerSchedule, error) {
+func buildCheckerSchedule(cfg *config.Config, cluster *checker.Cluster) ([]scheduler.CheckerSchedule, error) {
 	var schedules []scheduler.CheckerSchedule
 	for _, chkCfg := range cfg.Checkers {
-		chk, err := checker.Build(&chkCfg, kubeClient)
+		chk, configure, err := checker.Build(&chkCfg, cluster)
 		if errors.Is(err, checker.ErrSkipChecker) {
-			klog.ErrorS(err, "Skipped checker", "name", chkCfg.Name)
+			klog.ErrorS(err, "Skipped checker", "name", chkCfg.Name, "cluster", cluster.Name)
+			configure()
 			continue
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to build checker %q: %w", chkCfg.Name, err)
+			return nil, fmt.Errorf("failed to build checker %q%s: %w", chkCfg.Name, ofCluster(cluster), err)
 		}
+		configure()
 		schedules = append(schedules, scheduler.CheckerSchedule{
-			Interval: chkCfg.Interval,
-			Timeout:  chkCfg.Timeout,
-			Checker:  chk,
+			Interval:          chkCfg.Interval,
+			Timeout:           chkCfg.Timeout,
+			RunOnStart:        chkCfg.RunOnStart,
+			Jitter:            chkCfg.Jitter,
+			OverlapPolicy:     chkCfg.OverlapPolicy,
+			MaxConcurrentRuns: chkCfg.MaxConcurrentRuns,
+			Checker:           chk,
 		})
 	}
 	return schedules, nil
 }
 
+// parseList parses a comma-separated list, e.g. of label keys, ignoring empty entries.
+func parseList(value string) []string {
+	var keys []string
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 func registerCheckers() {
 	dnscheck.Register()
 	podstartup.Register()
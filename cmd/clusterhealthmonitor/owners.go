@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultOwnerDeployment = "kube-system/cluster-health-monitor"
+	defaultOwnerAnchor     = "cluster-health-monitor-anchor"
+)
+
+// resolveOwners looks up the Deployment of the monitor, in the form <namespace>/<name>, and the cluster-scoped anchor ClusterRole that
+// own the objects created by checkers. An owner that is not set or cannot be found is skipped, so that the monitor also runs outside of
+// its Deployment, e.g. with a kubeconfig during development. It returns nil if neither owner is found.
+func resolveOwners(ctx context.Context, kubeClient kubernetes.Interface, deployment, anchor string) (*checker.Owners, error) {
+	owners := &checker.Owners{}
+	if deployment != "" {
+		namespace, name, ok := strings.Cut(deployment, "/")
+		if !ok || namespace == "" || name == "" {
+			return nil, fmt.Errorf("invalid owner deployment %q, expected <namespace>/<name>", deployment)
+		}
+		d, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			klog.ErrorS(err, "Failed to get owner Deployment, namespaced checker objects are not owned by it", "namespace", namespace,
+				"name", name)
+		} else {
+			owners.Namespace = namespace
+			owners.Namespaced = &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: d.Name, UID: d.UID}
+		}
+	}
+	if anchor != "" {
+		cr, err := kubeClient.RbacV1().ClusterRoles().Get(ctx, anchor, metav1.GetOptions{})
+		if err != nil {
+			klog.ErrorS(err, "Failed to get owner anchor ClusterRole, cluster-scoped checker objects are not owned by it", "name", anchor)
+		} else {
+			owners.Cluster = &metav1.OwnerReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: cr.Name, UID: cr.UID}
+		}
+	}
+	if owners.Namespaced == nil && owners.Cluster == nil {
+		return nil, nil
+	}
+	return owners, nil
+}
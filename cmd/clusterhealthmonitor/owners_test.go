@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolveOwners(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "cluster-health-monitor", Namespace: "kube-system", UID: "deployment-uid"}}
+	anchor := &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: defaultOwnerAnchor, UID: "anchor-uid"}}
+	deploymentRef := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "cluster-health-monitor", UID: "deployment-uid"}
+	anchorRef := &metav1.OwnerReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: defaultOwnerAnchor, UID: "anchor-uid"}
+
+	testCases := []struct {
+		name        string
+		client      *k8sfake.Clientset
+		deployment  string
+		anchor      string
+		expected    *checker.Owners
+		expectedErr bool
+	}{
+		{
+			name:       "both owners",
+			client:     k8sfake.NewClientset(deployment, anchor),
+			deployment: defaultOwnerDeployment,
+			anchor:     defaultOwnerAnchor,
+			expected:   &checker.Owners{Namespace: "kube-system", Namespaced: deploymentRef, Cluster: anchorRef},
+		},
+		{
+			name:       "missing anchor",
+			client:     k8sfake.NewClientset(deployment),
+			deployment: defaultOwnerDeployment,
+			anchor:     defaultOwnerAnchor,
+			expected:   &checker.Owners{Namespace: "kube-system", Namespaced: deploymentRef},
+		},
+		{
+			name:       "no owners found",
+			client:     k8sfake.NewClientset(),
+			deployment: defaultOwnerDeployment,
+			anchor:     defaultOwnerAnchor,
+		},
+		{
+			name:   "owners disabled",
+			client: k8sfake.NewClientset(deployment, anchor),
+		},
+		{
+			name:        "invalid deployment",
+			client:      k8sfake.NewClientset(),
+			deployment:  "cluster-health-monitor",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			owners, err := resolveOwners(context.Background(), tc.client, tc.deployment, tc.anchor)
+			if tc.expectedErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(owners).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	"k8s.io/klog/v2"
+)
+
+// configReloader applies configuration changes to the scheduler of every cluster. Checkers are matched by name: added checkers are
+// started, removed checkers are stopped and garbage collected, and changed checkers are rebuilt and restarted.
+type configReloader struct {
+	clusters []clusterScheduler
+
+	mu sync.Mutex
+	// checkers holds the configuration of each applied checker, keyed by checker name.
+	checkers map[string]config.CheckerConfig
+	// resultSinks and webhooks hold the applied configuration of the result sinks. The sinks are only rebuilt if it changes, since
+	// rebuilding the webhook sink discards its queued notifications and its deduplication state.
+	resultSinks []config.ResultSinkType
+	webhooks    []config.WebhookConfig
+}
+
+func newConfigReloader(cfg *config.Config, clusters []clusterScheduler) *configReloader {
+	checkers := make(map[string]config.CheckerConfig, len(cfg.Checkers))
+	for _, chkCfg := range cfg.Checkers {
+		checkers[chkCfg.Name] = chkCfg
+	}
+	return &configReloader{
+		clusters:    clusters,
+		checkers:    checkers,
+		resultSinks: cfg.ResultSinks,
+		webhooks:    cfg.Webhooks,
+	}
+}
+
+// apply applies a validated configuration. All added and changed checkers are built for every cluster before any schedule is touched, and
+// their thresholds and target limits are only applied once the previous schedules are removed, so that the previous configuration keeps
+// running unchanged if any of them fails to build. A checker that fails to be scheduled in any cluster, e.g. because a HealthCheck of the
+// same name is scheduled there, is stopped in all clusters and not recorded as applied, so that it is started again when the returned
+// error makes the watcher retry the configuration.
+func (r *configReloader) apply(cfg *config.Config) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	desired := make(map[string]config.CheckerConfig, len(cfg.Checkers))
+	var toStop []string
+	// toStart holds the schedules to start in each cluster, in the order of r.clusters.
+	toStart := make([][]scheduler.CheckerSchedule, len(r.clusters))
+	// configures holds the configure funcs of the built checkers, which are called once the previous schedules are removed.
+	var configures []func()
+	for _, chkCfg := range cfg.Checkers {
+		desired[chkCfg.Name] = chkCfg
+		current, exists := r.checkers[chkCfg.Name]
+		if exists && reflect.DeepEqual(current, chkCfg) {
+			continue
+		}
+		if exists {
+			toStop = append(toStop, chkCfg.Name)
+		}
+
+		for i, cs := range r.clusters {
+			chk, configure, err := checker.Build(&chkCfg, cs.cluster)
+			if errors.Is(err, checker.ErrSkipChecker) {
+				klog.ErrorS(err, "Skipped checker", "name", chkCfg.Name, "cluster", cs.cluster.Name)
+			} else if err != nil {
+				return fmt.Errorf("failed to build checker %q%s: %w", chkCfg.Name, ofCluster(cs.cluster), err)
+			}
+			configures = append(configures, configure)
+			if chk != nil {
+				toStart[i] = append(toStart[i], scheduler.CheckerSchedule{
+					Interval:          chkCfg.Interval,
+					Timeout:           chkCfg.Timeout,
+					RunOnStart:        chkCfg.RunOnStart,
+					Jitter:            chkCfg.Jitter,
+					OverlapPolicy:     chkCfg.OverlapPolicy,
+					MaxConcurrentRuns: chkCfg.MaxConcurrentRuns,
+					Checker:           chk,
+				})
+			}
+		}
+	}
+	if !reflect.DeepEqual(r.resultSinks, cfg.ResultSinks) || !reflect.DeepEqual(r.webhooks, cfg.Webhooks) {
+		if err := checker.SetResultSinks(cfg); err != nil {
+			return fmt.Errorf("failed to set result sinks: %w", err)
+		}
+		r.resultSinks, r.webhooks = cfg.ResultSinks, cfg.Webhooks
+		klog.InfoS("Rebuilt result sinks", "numResultSinks", len(cfg.ResultSinks), "numWebhooks", len(cfg.Webhooks))
+	}
+
+	var removed []config.CheckerConfig
+	for name, current := range r.checkers {
+		if _, ok := desired[name]; !ok {
+			toStop = append(toStop, name)
+			removed = append(removed, current)
+		}
+	}
+
+	// The schedules are removed before the gauges and results of removed checkers are cleared, since Remove waits for the runs in progress,
+	// which record results again.
+	for _, cs := range r.clusters {
+		for _, name := range toStop {
+			cs.sched.Remove(name)
+		}
+	}
+	for _, cs := range r.clusters {
+		for _, chkCfg := range removed {
+			checker.ClearChecker(cs.cluster.Name, &chkCfg)
+		}
+	}
+	for _, configure := range configures {
+		configure()
+	}
+
+	var errs []error
+	// started holds the names of the checkers started in each cluster, in the order of r.clusters.
+	started := make([][]string, len(r.clusters))
+	failed := make(map[string]bool)
+	for i, cs := range r.clusters {
+		for _, chkSch := range toStart[i] {
+			name := chkSch.Checker.Name()
+			if err := cs.sched.Add(chkSch); err != nil {
+				errs = append(errs, fmt.Errorf("failed to schedule checker %q%s: %w", name, ofCluster(cs.cluster), err))
+				failed[name] = true
+				continue
+			}
+			started[i] = append(started[i], name)
+		}
+	}
+	numStarted := 0
+	for i, cs := range r.clusters {
+		for _, name := range started[i] {
+			if failed[name] {
+				cs.sched.Remove(name)
+				continue
+			}
+			numStarted++
+		}
+	}
+	for name := range failed {
+		delete(desired, name)
+	}
+	r.checkers = desired
+	klog.InfoS("Applied config", "numCheckers", len(desired), "numClusters", len(r.clusters), "numStopped", len(toStop),
+		"numStarted", numStarted)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	. "github.com/onsi/gomega"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigReloader_ResultSinks(t *testing.T) {
+	g := NewWithT(t)
+	const sinkType config.ResultSinkType = "Nop"
+	builds := 0
+	checker.RegisterResultSink(sinkType, func(*config.Config) (checker.ResultSink, error) {
+		builds++
+		return nopSink{}, nil
+	})
+	t.Cleanup(func() { _ = checker.SetResultSinks(&config.Config{}) })
+
+	newConfig := func(interval time.Duration, webhookURL string) *config.Config {
+		return &config.Config{
+			Checkers:    []config.CheckerConfig{{Name: "dns", Type: config.CheckTypeDNS, Interval: interval, Timeout: time.Second}},
+			ResultSinks: []config.ResultSinkType{sinkType},
+			Webhooks:    []config.WebhookConfig{{Name: "hook", URL: webhookURL}},
+		}
+	}
+	reloader := newConfigReloader(newConfig(time.Minute, "https://a.example.com"), nil)
+
+	g.Expect(reloader.apply(newConfig(2*time.Minute, "https://a.example.com"))).To(Succeed())
+	g.Expect(builds).To(BeZero(), "the sinks are kept if only checkers changed")
+
+	g.Expect(reloader.apply(newConfig(2*time.Minute, "https://b.example.com"))).To(Succeed())
+	g.Expect(builds).To(Equal(1), "the sinks are rebuilt if the webhooks changed")
+
+	g.Expect(reloader.apply(newConfig(time.Minute, "https://b.example.com"))).To(Succeed())
+	g.Expect(builds).To(Equal(1))
+}
+
+// reloadFakeChecker is a checker that does nothing.
+type reloadFakeChecker struct {
+	name string
+}
+
+func (f *reloadFakeChecker) Name() string             { return f.name }
+func (f *reloadFakeChecker) Type() config.CheckerType { return reloadFakeType }
+func (f *reloadFakeChecker) Run(ctx context.Context)  {}
+
+const reloadFakeType config.CheckerType = "ReloadFake"
+
+func TestConfigReloader_ScheduleFailure(t *testing.T) {
+	g := NewWithT(t)
+	checker.RegisterChecker(reloadFakeType, func(cfg *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
+		return &reloadFakeChecker{name: cfg.Name}, nil
+	})
+	chkCfg := config.CheckerConfig{Name: "reloaded", Type: reloadFakeType, Interval: time.Minute, Timeout: time.Second}
+	// The checker name is taken in the second cluster, e.g. by a HealthCheck.
+	owner := &reloadFakeChecker{name: chkCfg.Name}
+	clusters := []clusterScheduler{
+		{cluster: &checker.Cluster{Name: "a", KubeClient: k8sfake.NewClientset()}, sched: scheduler.NewScheduler(nil)},
+		{
+			cluster: &checker.Cluster{Name: "b", KubeClient: k8sfake.NewClientset()},
+			sched:   scheduler.NewScheduler([]scheduler.CheckerSchedule{{Interval: time.Minute, Timeout: time.Second, Checker: owner}}),
+		},
+	}
+	t.Cleanup(func() {
+		for _, cs := range clusters {
+			checker.ClearChecker(cs.cluster.Name, &chkCfg)
+		}
+	})
+	reloader := newConfigReloader(&config.Config{}, clusters)
+	cfg := &config.Config{Checkers: []config.CheckerConfig{chkCfg}}
+
+	for range 2 {
+		g.Expect(reloader.apply(cfg)).To(MatchError(ContainSubstring(`failed to schedule checker "reloaded" of cluster "b"`)),
+			"the configuration is retried until the checker is scheduled")
+		g.Expect(clusters[0].sched.Checkers()).To(BeEmpty())
+		g.Expect(clusters[1].sched.Checkers()).To(ConsistOf(BeIdenticalTo(owner)))
+	}
+
+	clusters[1].sched.Remove(chkCfg.Name)
+	g.Expect(reloader.apply(cfg)).To(Succeed())
+	g.Expect(clusters[0].sched.Checkers()).To(HaveLen(1))
+	g.Expect(clusters[1].sched.Checkers()).To(HaveLen(1))
+}
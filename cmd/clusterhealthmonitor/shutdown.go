@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// waitForShutdown blocks until ctx is done, e.g. on SIGTERM, and then shuts down the schedulers of all clusters. It stops scheduling new
+// runs and lets the runs in progress complete, aborting them after gracePeriod. The runs delete the resources they created with a
+// separate context, so they are cleaned up either way. Then it sweeps whatever the checkers left behind within sweepTimeout. The
+// schedulers of all clusters are stopped and swept concurrently.
+func waitForShutdown(ctx context.Context, css []clusterScheduler, gracePeriod, sweepTimeout time.Duration) {
+	<-ctx.Done()
+	klog.InfoS("Shutting down Cluster Health Monitor")
+
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancelStop()
+	var wg sync.WaitGroup
+	for _, cs := range css {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cs.sched.Stop(stopCtx)
+			sweepCtx, cancelSweep := context.WithTimeout(context.Background(), sweepTimeout)
+			defer cancelSweep()
+			if err := cs.sched.Sweep(sweepCtx); err != nil {
+				klog.ErrorS(err, "Failed to sweep checker resources on shutdown", "cluster", cs.cluster.Name)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	. "github.com/onsi/gomega"
+)
+
+// fakeSweepingChecker counts its runs and the calls to Sweep.
+type fakeSweepingChecker struct {
+	runs   atomic.Int32
+	sweeps atomic.Int32
+}
+
+func (f *fakeSweepingChecker) Name() string             { return "sweeping" }
+func (f *fakeSweepingChecker) Type() config.CheckerType { return config.CheckTypeAPIServer }
+func (f *fakeSweepingChecker) Run(ctx context.Context)  { f.runs.Add(1) }
+
+func (f *fakeSweepingChecker) Sweep(ctx context.Context) error {
+	f.sweeps.Add(1)
+	return nil
+}
+
+func TestWaitForShutdown(t *testing.T) {
+	g := NewWithT(t)
+	chk := &fakeSweepingChecker{}
+	sched := scheduler.NewScheduler([]scheduler.CheckerSchedule{
+		{Interval: time.Hour, Timeout: time.Second, RunOnStart: true, Checker: chk},
+	})
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- sched.Start(context.Background())
+	}()
+	g.Eventually(chk.runs.Load).Should(Equal(int32(1)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		waitForShutdown(ctx, []clusterScheduler{{cluster: &checker.Cluster{}, sched: sched}}, time.Second, time.Second)
+	}()
+	g.Consistently(done).ShouldNot(BeClosed(), "the schedulers run until the root context is canceled")
+	g.Expect(chk.sweeps.Load()).To(BeZero())
+
+	cancel()
+	g.Eventually(done).Should(BeClosed())
+	g.Expect(startErr).To(Receive(BeNil()), "the scheduler is stopped")
+	g.Expect(chk.sweeps.Load()).To(Equal(int32(1)), "the checker resources are swept")
+}
@@ -0,0 +1,211 @@
+// Package admin provides an HTTP API to list the scheduled checkers, trigger a run of a checker and pause or resume the schedule of a
+// checker. It changes the behavior of the monitor, so it must only be served with authentication and authorization.
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	"k8s.io/klog/v2"
+)
+
+// Path is the path under which the admin API is served:
+//   - GET /api/v1/admin/checkers lists the scheduled checkers.
+//   - POST /api/v1/admin/checkers/{name}/run runs the checker and responds with the results of the run once it completes.
+//   - POST /api/v1/admin/checkers/{name}/pause pauses the schedule of the checker.
+//   - POST /api/v1/admin/checkers/{name}/resume resumes the schedule of the checker.
+const Path = "/api/v1/admin"
+
+// ListResponse is the response body of the checker list.
+type ListResponse struct {
+	// Checkers holds every scheduled checker, sorted by name.
+	Checkers []CheckerInfo `json:"checkers"`
+}
+
+// CheckerInfo describes a scheduled checker.
+type CheckerInfo struct {
+	// Name is the name of the checker.
+	Name string `json:"name"`
+	// Type is the type of the checker.
+	Type string `json:"type"`
+	// Paused is whether the scheduled runs of the checker are paused.
+	Paused bool `json:"paused"`
+}
+
+// RunResponse is the response body of a triggered run.
+type RunResponse struct {
+	// Name is the name of the checker.
+	Name string `json:"name"`
+	// Type is the type of the checker.
+	Type string `json:"type"`
+	// RunID is the unique ID of the run. It is empty if the run did not record any result.
+	RunID string `json:"runID,omitempty"`
+	// Result is the checker-level result of the run. It is nil if the run failed with an error, or only recorded target results.
+	Result *checker.Result `json:"result,omitempty"`
+	// Error is the error the run failed with. It is also set if the run did not record any result.
+	Error string `json:"error,omitempty"`
+	// Targets holds the results recorded for each target during the run, for checkers that record per-target results.
+	Targets []TargetResult `json:"targets,omitempty"`
+}
+
+// TargetResult is the result of a triggered run for a single target.
+type TargetResult struct {
+	// Name is the name of the target.
+	Name string `json:"name"`
+	// Result is the result for the target. It is nil if the target failed with an error.
+	Result *checker.Result `json:"result,omitempty"`
+	// Error is the error the target failed with.
+	Error string `json:"error,omitempty"`
+}
+
+// NewHandler returns an HTTP handler that serves the admin API for the scheduler returned by sched. It responds with 503 while sched
+// returns nil, i.e. before the checkers have been built.
+func NewHandler(sched func() *scheduler.Scheduler) http.Handler {
+	h := &handler{sched: sched}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Path+"/checkers", h.list)
+	mux.HandleFunc("POST "+Path+"/checkers/{name}/run", h.run)
+	mux.HandleFunc("POST "+Path+"/checkers/{name}/pause", h.pause)
+	mux.HandleFunc("POST "+Path+"/checkers/{name}/resume", h.resume)
+	return mux
+}
+
+type handler struct {
+	sched func() *scheduler.Scheduler
+}
+
+// scheduler returns the scheduler, or responds with 503 and returns nil if it has not been set yet.
+func (h *handler) scheduler(w http.ResponseWriter) *scheduler.Scheduler {
+	s := h.sched()
+	if s == nil {
+		http.Error(w, "checkers have not been built", http.StatusServiceUnavailable)
+	}
+	return s
+}
+
+func (h *handler) list(w http.ResponseWriter, _ *http.Request) {
+	s := h.scheduler(w)
+	if s == nil {
+		return
+	}
+	resp := ListResponse{Checkers: []CheckerInfo{}}
+	for _, chk := range s.Checkers() {
+		resp.Checkers = append(resp.Checkers, CheckerInfo{
+			Name:   chk.Name(),
+			Type:   string(chk.Type()),
+			Paused: s.Paused(chk.Name()),
+		})
+	}
+	writeJSON(w, resp)
+}
+
+func (h *handler) run(w http.ResponseWriter, r *http.Request) {
+	s := h.scheduler(w)
+	if s == nil {
+		return
+	}
+	name := r.PathValue("name")
+	chk := findChecker(s, name)
+	if chk == nil {
+		http.Error(w, scheduler.ErrCheckerNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	records, err := s.RunNow(r.Context(), name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, newRunResponse(chk, records))
+}
+
+func (h *handler) pause(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, true)
+}
+
+func (h *handler) resume(w http.ResponseWriter, r *http.Request) {
+	h.setPaused(w, r, false)
+}
+
+func (h *handler) setPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	s := h.scheduler(w)
+	if s == nil {
+		return
+	}
+	name := r.PathValue("name")
+	var err error
+	if paused {
+		err = s.Pause(name)
+	} else {
+		err = s.Resume(name)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	chk := findChecker(s, name)
+	if chk == nil {
+		http.Error(w, scheduler.ErrCheckerNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, CheckerInfo{Name: name, Type: string(chk.Type()), Paused: s.Paused(name)})
+}
+
+// findChecker returns the named checker of the scheduler, or nil if it is not scheduled.
+func findChecker(s *scheduler.Scheduler, name string) checker.Checker {
+	for _, chk := range s.Checkers() {
+		if chk.Name() == name {
+			return chk
+		}
+	}
+	return nil
+}
+
+// newRunResponse returns the response for the results recorded during a triggered run of a checker.
+func newRunResponse(chk checker.Checker, records []checker.ResultRecord) RunResponse {
+	resp := RunResponse{Name: chk.Name(), Type: string(chk.Type())}
+	reported := false
+	for _, record := range records {
+		resp.RunID = record.RunID
+		if record.Target != "" {
+			tr := TargetResult{Name: record.Target, Result: record.Result}
+			if record.Err != nil {
+				tr.Error = record.Err.Error()
+			}
+			resp.Targets = append(resp.Targets, tr)
+			continue
+		}
+		reported = true
+		resp.Result = record.Result
+		if record.Err != nil {
+			resp.Result = nil
+			resp.Error = record.Err.Error()
+		}
+	}
+	if !reported && len(resp.Targets) == 0 {
+		resp.Error = "checker did not record a result"
+	}
+	return resp
+}
+
+// writeError responds with the status code matching an error of the scheduler.
+func writeError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, scheduler.ErrCheckerNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, scheduler.ErrRunConditionNotMet):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		klog.ErrorS(err, "Failed to handle admin request")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.ErrorS(err, "Failed to write admin response")
+	}
+}
@@ -0,0 +1,124 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	. "github.com/onsi/gomega"
+)
+
+// fakeChecker records a checker-level result, or one result per target if targets is set.
+type fakeChecker struct {
+	name    string
+	targets []string
+	err     error
+}
+
+func (f *fakeChecker) Name() string             { return f.name }
+func (f *fakeChecker) Type() config.CheckerType { return config.CheckerType("fake") }
+func (f *fakeChecker) Run(ctx context.Context) {
+	if len(f.targets) == 0 {
+		if f.err != nil {
+			checker.RecordResult(ctx, f, nil, f.err)
+			return
+		}
+		checker.RecordResult(ctx, f, checker.Unhealthy("CODE", "message"), nil)
+		return
+	}
+	for _, target := range f.targets {
+		checker.RecordTargetResult(ctx, f, target, checker.Healthy(), nil)
+	}
+}
+
+func startScheduler(t *testing.T, chks ...checker.Checker) *scheduler.Scheduler {
+	var schedules []scheduler.CheckerSchedule
+	for _, chk := range chks {
+		schedules = append(schedules, scheduler.CheckerSchedule{Interval: time.Hour, Timeout: time.Second, Checker: chk})
+	}
+	s := scheduler.NewScheduler(schedules)
+	s.SetRunCondition(func(chk checker.Checker) bool { return chk.Name() != "blocked" })
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = s.Start(ctx)
+	}()
+	NewWithT(t).Eventually(s.Healthy, time.Second, 10*time.Millisecond).Should(Succeed())
+	return s
+}
+
+func serve(handler http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestHandler_Run(t *testing.T) {
+	g := NewWithT(t)
+	s := startScheduler(t,
+		&fakeChecker{name: "unhealthy"},
+		&fakeChecker{name: "failing", err: errors.New("boom")},
+		&fakeChecker{name: "targets", targets: []string{"a", "b"}},
+		&fakeChecker{name: "blocked"},
+	)
+	handler := NewHandler(func() *scheduler.Scheduler { return s })
+
+	rec := serve(handler, http.MethodPost, Path+"/checkers/unhealthy/run")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	var resp RunResponse
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(Succeed())
+	g.Expect(resp.RunID).NotTo(BeEmpty())
+	resp.RunID = ""
+	g.Expect(resp).To(Equal(RunResponse{Name: "unhealthy", Type: "fake", Result: checker.Unhealthy("CODE", "message")}))
+
+	rec = serve(handler, http.MethodPost, Path+"/checkers/failing/run")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(ContainSubstring(`"error":"boom"`))
+
+	rec = serve(handler, http.MethodPost, Path+"/checkers/targets/run")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	resp = RunResponse{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(Succeed())
+	g.Expect(resp.Result).To(BeNil())
+	g.Expect(resp.Error).To(BeEmpty())
+	g.Expect(resp.Targets).To(Equal([]TargetResult{{Name: "a", Result: checker.Healthy()}, {Name: "b", Result: checker.Healthy()}}))
+
+	g.Expect(serve(handler, http.MethodPost, Path+"/checkers/blocked/run").Code).To(Equal(http.StatusConflict))
+	g.Expect(serve(handler, http.MethodPost, Path+"/checkers/missing/run").Code).To(Equal(http.StatusNotFound))
+	g.Expect(serve(handler, http.MethodGet, Path+"/checkers/unhealthy/run").Code).To(Equal(http.StatusMethodNotAllowed))
+}
+
+func TestHandler_PauseResume(t *testing.T) {
+	g := NewWithT(t)
+	s := startScheduler(t, &fakeChecker{name: "dns"}, &fakeChecker{name: "apiserver"})
+	handler := NewHandler(func() *scheduler.Scheduler { return s })
+
+	rec := serve(handler, http.MethodPost, Path+"/checkers/dns/pause")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(MatchJSON(`{"name": "dns", "type": "fake", "paused": true}`))
+	g.Expect(s.Paused("dns")).To(BeTrue())
+
+	rec = serve(handler, http.MethodGet, Path+"/checkers")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Body.String()).To(MatchJSON(`{"checkers": [
+		{"name": "apiserver", "type": "fake", "paused": false},
+		{"name": "dns", "type": "fake", "paused": true}
+	]}`))
+
+	g.Expect(serve(handler, http.MethodPost, Path+"/checkers/dns/resume").Code).To(Equal(http.StatusOK))
+	g.Expect(s.Paused("dns")).To(BeFalse())
+	g.Expect(serve(handler, http.MethodPost, Path+"/checkers/missing/pause").Code).To(Equal(http.StatusNotFound))
+}
+
+func TestHandler_SchedulerNotSet(t *testing.T) {
+	g := NewWithT(t)
+	handler := NewHandler(func() *scheduler.Scheduler { return nil })
+	g.Expect(serve(handler, http.MethodGet, Path+"/checkers").Code).To(Equal(http.StatusServiceUnavailable))
+}
@@ -19,6 +19,17 @@ import (
 	"github.com/Azure/cluster-health-monitor/pkg/config"
 )
 
+const (
+	// These are the steps of the APIServerChecker's run whose durations are recorded.
+	stepCreate = "Create"
+	stepGet    = "Get"
+	stepDelete = "Delete"
+
+	// These are the additional steps of the APIServerChecker's run that are traced.
+	stepGarbageCollect = "GarbageCollect"
+	stepList           = "List"
+)
+
 // APIServerChecker implements the Checker interface for API server checks.
 type APIServerChecker struct {
 	name       string
@@ -32,12 +43,12 @@ func Register() {
 }
 
 // buildAPIServerChecker creates a new APIServerChecker instance.
-func buildAPIServerChecker(config *config.CheckerConfig, kubeClient kubernetes.Interface) (checker.Checker, error) {
+func buildAPIServerChecker(config *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
 	chk := &APIServerChecker{
 		name:       config.Name,
 		config:     config.APIServerConfig,
 		timeout:    config.Timeout,
-		kubeClient: kubeClient,
+		kubeClient: cluster.KubeClient,
 	}
 	klog.InfoS("Built APIServerChecker",
 		"name", chk.name,
@@ -57,7 +68,7 @@ func (c APIServerChecker) Type() config.CheckerType {
 
 func (c APIServerChecker) Run(ctx context.Context) {
 	result, err := c.check(ctx)
-	checker.RecordResult(c, result, err)
+	checker.RecordResult(ctx, c, result, err)
 }
 
 // check executes the API server check.
@@ -65,16 +76,21 @@ func (c APIServerChecker) Run(ctx context.Context) {
 // If all operations succeed, the check is considered healthy.
 func (c APIServerChecker) check(ctx context.Context) (*checker.Result, error) {
 	// Garbage collect any leftover ConfigMaps previously created by this checker.
-	if err := c.garbageCollect(ctx); err != nil {
+	gcCtx, gcSpan := checker.StartSpan(ctx, stepGarbageCollect)
+	err := c.garbageCollect(gcCtx)
+	checker.EndSpan(gcSpan, err)
+	if err != nil {
 		// Logging instead of returning an error to avoid failing the checker run.
 		klog.ErrorS(err, "Failed to garbage collect old ConfigMaps")
 	}
 
 	// Check if the ConfigMap limit has been reached.
 	// Do not run the checker if the maximum number been reached.
-	configMapList, err := c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).List(ctx, metav1.ListOptions{
+	listCtx, listSpan := checker.StartSpan(ctx, stepList)
+	configMapList, err := c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).List(listCtx, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(labels.Set(c.configMapLabels())).String(),
 	})
+	checker.EndSpan(listSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list ConfigMaps: %w", err)
 	}
@@ -86,16 +102,23 @@ func (c APIServerChecker) check(ctx context.Context) (*checker.Result, error) {
 	// Create ConfigMap.
 	createCtx, createCancel := context.WithTimeout(ctx, c.config.MutateTimeout)
 	defer createCancel()
+	createCtx, createSpan := checker.StartSpan(createCtx, stepCreate)
+	createStart := time.Now()
 	createdConfigMap, err := c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).Create(createCtx, c.generateConfigMap(), metav1.CreateOptions{})
+	checker.RecordStepDuration(ctx, c, stepCreate, time.Since(createStart))
+	checker.EndSpan(createSpan, err)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodeAPIServerCreateTimeout, "timed out while creating ConfigMap"), nil
 		}
 		return checker.Unhealthy(ErrCodeAPIServerCreateError, fmt.Sprintf("failed to create ConfigMap: %v", err)), nil
 	}
-	// Defer deletion of the created ConfigMap in case of failure later in the function.
+	// Defer deletion of the created ConfigMap in case of failure later in the function. The deletion uses a separate context so that the
+	// ConfigMap is also deleted if the run timed out or the monitor is shutting down.
 	defer func() {
-		err := c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).Delete(ctx, createdConfigMap.Name, metav1.DeleteOptions{})
+		cleanupCtx, cancel := checker.CleanupContext(ctx)
+		defer cancel()
+		err := c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).Delete(cleanupCtx, createdConfigMap.Name, metav1.DeleteOptions{})
 		if err != nil && !apierrors.IsNotFound(err) {
 			// Logging instead of returning an error here to avoid failing the checker run.
 			klog.ErrorS(err, "Failed to delete ConfigMap", "name", createdConfigMap.Name)
@@ -105,7 +128,11 @@ func (c APIServerChecker) check(ctx context.Context) (*checker.Result, error) {
 	// Get ConfigMap.
 	getCtx, getCancel := context.WithTimeout(ctx, c.config.ReadTimeout)
 	defer getCancel()
+	getCtx, getSpan := checker.StartSpan(getCtx, stepGet)
+	getStart := time.Now()
 	_, err = c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).Get(getCtx, createdConfigMap.Name, metav1.GetOptions{})
+	checker.RecordStepDuration(ctx, c, stepGet, time.Since(getStart))
+	checker.EndSpan(getSpan, err)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodeAPIServerGetTimeout, "timed out while getting ConfigMap"), nil
@@ -116,7 +143,11 @@ func (c APIServerChecker) check(ctx context.Context) (*checker.Result, error) {
 	// Delete ConfigMap.
 	deleteCtx, deleteCancel := context.WithTimeout(ctx, c.config.MutateTimeout)
 	defer deleteCancel()
+	deleteCtx, deleteSpan := checker.StartSpan(deleteCtx, stepDelete)
+	deleteStart := time.Now()
 	err = c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).Delete(deleteCtx, createdConfigMap.Name, metav1.DeleteOptions{})
+	checker.RecordStepDuration(ctx, c, stepDelete, time.Since(deleteStart))
+	checker.EndSpan(deleteSpan, err)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodeAPIServerDeleteTimeout, "timed out while deleting ConfigMap"), nil
@@ -139,16 +170,42 @@ func (c APIServerChecker) configMapLabels() map[string]string {
 func (c APIServerChecker) generateConfigMap() *corev1.ConfigMap {
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-empty-configmap-%d", strings.ToLower(c.name), time.Now().UnixNano()),
-			Namespace: c.config.Namespace,
-			Labels:    c.configMapLabels(),
+			Name:            fmt.Sprintf("%s-empty-configmap-%d", strings.ToLower(c.name), time.Now().UnixNano()),
+			Namespace:       c.config.Namespace,
+			Labels:          c.configMapLabels(),
+			OwnerReferences: checker.OwnerReferences(c.config.Namespace),
 		},
 	}
 }
 
+// GarbageCollect implements checker.GarbageCollector.
+func (c APIServerChecker) GarbageCollect(ctx context.Context) error {
+	return c.garbageCollect(ctx)
+}
+
+// Sweep implements checker.Sweeper.
+func (c APIServerChecker) Sweep(ctx context.Context) error {
+	return c.deleteConfigMapsOlderThan(ctx, 0)
+}
+
+// ResourceLabelKey implements checker.ResourceOwner.
+func (c APIServerChecker) ResourceLabelKey() string {
+	return c.config.LabelKey
+}
+
+// OwnsResource implements checker.ResourceOwner. The checker owns the ConfigMaps in its namespace that are labeled with its name.
+func (c APIServerChecker) OwnsResource(obj metav1.Object) bool {
+	return obj.GetNamespace() == c.config.Namespace && obj.GetLabels()[c.config.LabelKey] == c.name
+}
+
 // garbageCollect attempts to delete any leftover ConfigMaps created by this checker
 // in previous runs that may not have been properly deleted.
 func (c APIServerChecker) garbageCollect(ctx context.Context) error {
+	return c.deleteConfigMapsOlderThan(ctx, c.timeout)
+}
+
+// deleteConfigMapsOlderThan deletes the ConfigMaps created by this checker that are older than minAge.
+func (c APIServerChecker) deleteConfigMapsOlderThan(ctx context.Context, minAge time.Duration) error {
 	configMapList, err := c.kubeClient.CoreV1().ConfigMaps(c.config.Namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(labels.Set(c.configMapLabels())).String(),
 	})
@@ -158,7 +215,7 @@ func (c APIServerChecker) garbageCollect(ctx context.Context) error {
 
 	var errs []error
 	for _, cm := range configMapList.Items {
-		if time.Since(cm.CreationTimestamp.Time) > c.timeout {
+		if time.Since(cm.CreationTimestamp.Time) > minAge {
 			err := c.kubeClient.CoreV1().ConfigMaps(cm.Namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
 			if err != nil && !apierrors.IsNotFound(err) {
 				errs = append(errs, fmt.Errorf("failed to delete old empty ConfigMap %s: %w", cm.Name, err))
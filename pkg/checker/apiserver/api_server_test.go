@@ -339,6 +339,61 @@ func TestAPIServerChecker_garbageCollect(t *testing.T) {
 	}
 }
 
+func TestAPIServerChecker_Sweep(t *testing.T) {
+	g := NewWithT(t)
+	checkerName := "test-api-server-checker"
+	configMapNamespace := "test-namespace"
+	configMapLabelKey := "cluster-health-monitor/checker-name"
+	client := k8sfake.NewSimpleClientset(
+		configMapWithLabels(checkerName+"-empty-configmap-old", configMapNamespace, map[string]string{configMapLabelKey: checkerName}, time.Now().Add(-time.Hour)),
+		configMapWithLabels(checkerName+"-empty-configmap-new", configMapNamespace, map[string]string{configMapLabelKey: checkerName}, time.Now().Add(-time.Second)),
+		configMapWithLabels("other-configmap", configMapNamespace, map[string]string{}, time.Now().Add(-time.Hour)),
+	)
+	checker := &APIServerChecker{
+		name:       checkerName,
+		config:     &config.APIServerConfig{Namespace: configMapNamespace, LabelKey: configMapLabelKey},
+		timeout:    time.Minute,
+		kubeClient: client,
+	}
+
+	g.Expect(checker.Sweep(context.Background())).To(Succeed())
+
+	configMaps, err := client.CoreV1().ConfigMaps(configMapNamespace).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(configMaps.Items).To(HaveLen(1), "all ConfigMaps of the checker are deleted regardless of their age")
+	g.Expect(configMaps.Items[0].Name).To(Equal("other-configmap"))
+}
+
+func TestAPIServerChecker_OwnsResource(t *testing.T) {
+	g := NewWithT(t)
+	labelKey := "cluster-health-monitor/checker-name"
+	checker := &APIServerChecker{
+		name:   "APIServer",
+		config: &config.APIServerConfig{Namespace: "kube-system", LabelKey: labelKey},
+	}
+
+	g.Expect(checker.ResourceLabelKey()).To(Equal(labelKey))
+	g.Expect(checker.OwnsResource(configMapWithLabels("apiserver-empty-configmap-1", "kube-system",
+		map[string]string{labelKey: "APIServer"}, time.Now()))).To(BeTrue())
+	g.Expect(checker.OwnsResource(configMapWithLabels("renamed-empty-configmap-1", "kube-system",
+		map[string]string{labelKey: "Renamed"}, time.Now()))).To(BeFalse(), "ConfigMaps of other checkers are not owned")
+	g.Expect(checker.OwnsResource(configMapWithLabels("apiserver-empty-configmap-1", "default",
+		map[string]string{labelKey: "APIServer"}, time.Now()))).To(BeFalse(), "ConfigMaps in other namespaces are not owned")
+}
+
+func TestAPIServerChecker_generateConfigMapOwnerReferences(t *testing.T) {
+	g := NewWithT(t)
+	owner := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "cluster-health-monitor", UID: "uid"}
+	checker.SetOwners(&checker.Owners{Namespace: "kube-system", Namespaced: &owner})
+	t.Cleanup(func() { checker.SetOwners(nil) })
+	chk := &APIServerChecker{
+		name:   "APIServer",
+		config: &config.APIServerConfig{Namespace: "kube-system", LabelKey: "cluster-health-monitor/checker-name"},
+	}
+
+	g.Expect(chk.generateConfigMap().OwnerReferences).To(Equal([]metav1.OwnerReference{owner}))
+}
+
 func TestAPIServerChecker_generateConfigMap(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -18,6 +18,9 @@ import (
 	"github.com/Azure/cluster-health-monitor/pkg/config"
 )
 
+// stepDryRunCreate is the step of the AzurePolicyChecker's run that is traced.
+const stepDryRunCreate = "DryRunCreatePod"
+
 // WarningCapture provides access to captured warning headers
 // This interface mainly exists so that it is possible to use a mock implementation in unit tests.
 type WarningCapture interface {
@@ -85,16 +88,15 @@ func Register() {
 }
 
 // buildAzurePolicyChecker creates a new AzurePolicyChecker instance.
-func buildAzurePolicyChecker(config *config.CheckerConfig, kubeClient kubernetes.Interface) (checker.Checker, error) {
-	restConfig, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+func buildAzurePolicyChecker(config *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
+	if cluster.RESTConfig == nil {
+		return nil, errors.New("cluster REST config cannot be nil")
 	}
 
 	return &AzurePolicyChecker{
 		name:          config.Name,
 		timeout:       config.Timeout,
-		restConfig:    restConfig,
+		restConfig:    cluster.RESTConfig,
 		clientFactory: &defaultClientFactory{},
 	}, nil
 }
@@ -109,7 +111,7 @@ func (c *AzurePolicyChecker) Type() config.CheckerType {
 
 func (c *AzurePolicyChecker) Run(ctx context.Context) {
 	result, err := c.check(ctx)
-	checker.RecordResult(c, result, err)
+	checker.RecordResult(ctx, c, result, err)
 }
 
 // check executes the Azure Policy check by doing a dry run creation of a test pod that violates default AKS Deployment Safeguards policies.
@@ -130,7 +132,10 @@ func (c *AzurePolicyChecker) check(ctx context.Context) (*checker.Result, error)
 	defer cancel()
 
 	// Perform dry-run creation to trigger Azure Policy validation. We do not actually want to create the pod, just validate the policy.
-	_, err = client.CoreV1().Pods("default").Create(timeoutCtx, c.createTestPod(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	// The span is not marked as failed if the request is denied, since a denial is the expected outcome when Azure Policy is enforced.
+	spanCtx, span := checker.StartSpan(timeoutCtx, stepDryRunCreate)
+	_, err = client.CoreV1().Pods("default").Create(spanCtx, c.createTestPod(), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	span.End()
 
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
@@ -2,11 +2,14 @@ package checker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Azure/cluster-health-monitor/pkg/config"
 	"github.com/Azure/cluster-health-monitor/pkg/metrics"
-	"k8s.io/client-go/kubernetes"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
 )
 
@@ -21,73 +24,277 @@ type Checker interface {
 	Run(ctx context.Context)
 }
 
-type Builder func(cfg *config.CheckerConfig, kubeClient kubernetes.Interface) (Checker, error)
+// GarbageCollector is implemented by checkers that create resources in the cluster. GarbageCollect deletes the resources left behind by
+// previous runs of the checker, e.g. once the checker is removed from the configuration.
+type GarbageCollector interface {
+	GarbageCollect(ctx context.Context) error
+}
+
+// Sweeper is implemented by checkers that create resources in the cluster. Unlike GarbageCollect, Sweep deletes all resources created by
+// the checker regardless of their age. It is called on shutdown once no run of the checker is in progress, so that the monitor does not
+// leave synthetic resources behind.
+type Sweeper interface {
+	Sweep(ctx context.Context) error
+}
+
+// ResourceOwner is implemented by checkers that create resources in the cluster. The reaper uses it to tell the resources of the active
+// checkers apart from the resources left behind by checkers that were renamed or removed from the configuration.
+type ResourceOwner interface {
+	// ResourceLabelKey returns the key of the label that marks the resources created by the checker.
+	ResourceLabelKey() string
+	// OwnsResource returns whether an object carrying the label key was created by the checker.
+	OwnsResource(obj metav1.Object) bool
+}
+
+// cleanupTimeout bounds the deletion of the resources created during a run once the run is over.
+const cleanupTimeout = 30 * time.Second
+
+// CleanupContext returns a context for deleting the resources created during a checker run, e.g. in a deferred call. Unlike ctx, it is not
+// canceled when the run times out or the monitor shuts down, so that the resources are not left behind, but it is bounded by its own
+// timeout. It keeps the values of ctx, such as the span of the run.
+func CleanupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+}
+
+// ReadOnlyChecker is implemented by checkers that only read from the cluster and never create or delete resources. Unlike other
+// checkers, they are safe to run on every replica of the monitor at the same time.
+type ReadOnlyChecker interface {
+	ReadOnly() bool
+}
+
+// IsReadOnly returns whether a checker implements ReadOnlyChecker and reports itself as read-only.
+func IsReadOnly(chk Checker) bool {
+	ro, ok := chk.(ReadOnlyChecker)
+	return ok && ro.ReadOnly()
+}
+
+// Builder creates a checker of a cluster from its config. Builders create the clients they need other than cluster.KubeClient from
+// cluster.RESTConfig, so that all checkers of a cluster share the same config.
+type Builder func(cfg *config.CheckerConfig, cluster *Cluster) (Checker, error)
 
 var checkerRegistry = make(map[config.CheckerType]Builder)
 
+// allStatuses are the status labels of the status gauges.
+var allStatuses = []string{metrics.HealthyStatus, metrics.UnhealthyStatus, metrics.UnknownStatus, metrics.SkippedStatus}
+
 func RegisterChecker(t config.CheckerType, builder Builder) {
 	checkerRegistry[t] = builder
 	klog.InfoS("Registered checker", "type", t)
 }
 
-// Build creates checkers from a list of checker configs
-func Build(cfg *config.CheckerConfig, kubeClient kubernetes.Interface) (Checker, error) {
-	if kubeClient == nil {
-		return nil, fmt.Errorf("kubernetes client cannot be nil")
+// Build creates a checker of a cluster from its config. It does not change any state shared with a running checker of the same name, so
+// that a failed build, e.g. of a config reload, leaves the running checker untouched. Instead, it returns a configure func that applies the
+// failure and success thresholds and the target limits of the config to the effective status and latest results of the checker, to be
+// called once the built checker replaces the running one. If the builder returns ErrSkipChecker, configure records the checker as skipped
+// so that it can be told apart from a checker that failed. configure is nil if Build returns any other error.
+func Build(cfg *config.CheckerConfig, cluster *Cluster) (chk Checker, configure func(), err error) {
+	if cluster == nil || cluster.KubeClient == nil {
+		return nil, nil, fmt.Errorf("kubernetes client cannot be nil")
 	}
 
 	builder, ok := checkerRegistry[cfg.Type]
 	if !ok {
-		return nil, fmt.Errorf("unrecognized checker type: %q", cfg.Type)
+		return nil, nil, fmt.Errorf("unrecognized checker type: %q", cfg.Type)
 	}
-	return builder(cfg, kubeClient)
+	chk, err = builder(cfg, cluster)
+	if errors.Is(err, ErrSkipChecker) {
+		return nil, func() {
+			metrics.CheckerSkippedGauge.WithLabelValues(cluster.Name, string(cfg.Type), cfg.Name).Set(1)
+		}, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return chk, func() {
+		ClearSkippedChecker(cluster.Name, cfg)
+		key := checkerKey{cluster: cluster.Name, name: cfg.Name}
+		effectiveStatuses.configure(key, cfg)
+		latestResults.configure(key, cfg)
+	}, nil
 }
 
-// RecordResult increments the result counter for a specific checker run.
+// ClearSkippedChecker removes the skipped record of a checker of the named cluster, e.g. once the checker is removed from the configuration.
+func ClearSkippedChecker(cluster string, cfg *config.CheckerConfig) {
+	metrics.CheckerSkippedGauge.DeleteLabelValues(cluster, string(cfg.Type), cfg.Name)
+}
+
+// ClearChecker removes the gauges recorded for a checker of the named cluster, i.e. its skipped record, its latest status and timestamps, and its effective
+// status and flapping gauges, as well as its latest results and the state that result sinks keep for it. It is called once the checker is removed from the
+// configuration and its schedule is removed, so that it does not look stale.
+func ClearChecker(cluster string, cfg *config.CheckerConfig) {
+	ClearSkippedChecker(cluster, cfg)
+	key := checkerKey{cluster: cluster, name: cfg.Name}
+	effectiveStatuses.forget(key)
+	latestResults.forget(key)
+	for _, sink := range *resultSinks.Load() {
+		if cleaner, ok := sink.(CheckerCleaner); ok {
+			cleaner.ForgetChecker(cluster, cfg.Name)
+		}
+	}
+	labels := prometheus.Labels{"cluster": cluster, "checker_name": cfg.Name}
+	metrics.CheckerStatusGauge.DeletePartialMatch(labels)
+	metrics.CheckerTargetStatusGauge.DeletePartialMatch(labels)
+	metrics.CheckerLastRunTimestamp.DeletePartialMatch(labels)
+	metrics.CheckerLastSuccessTimestamp.DeletePartialMatch(labels)
+	metrics.CheckerTargetLastSuccessTimestamp.DeletePartialMatch(labels)
+	metrics.CheckerEffectiveStatusGauge.DeletePartialMatch(labels)
+	metrics.CheckerTargetEffectiveStatusGauge.DeletePartialMatch(labels)
+	metrics.CheckerFlappingGauge.DeletePartialMatch(labels)
+	metrics.CheckerTargetFlappingGauge.DeletePartialMatch(labels)
+}
+
+// RecordResult records the result of a checker run and writes it to the result sinks.
 // If err is not nil, it records a run error (unknown status).
 // If result is not nil, it records the status from the result.
-func RecordResult(checker Checker, result *Result, err error) {
-	checkerType := string(checker.Type())
-	checkerName := checker.Name()
-	// If there's an error, record as unknown.
+func RecordResult(ctx context.Context, checker Checker, result *Result, err error) {
+	recordResult(ctx, checker, "", result, err)
+}
+
+// RecordTargetResult records the result of a checker for a single target, e.g. a CoreDNS pod, an API server endpoint or a node, and writes
+// it to the result sinks. Checkers that check several targets record one result per target and run.
+// If err is not nil, it records a run error (unknown status).
+// If result is not nil, it records the status from the result.
+func RecordTargetResult(ctx context.Context, checker Checker, target string, result *Result, err error) {
+	recordResult(ctx, checker, target, result, err)
+}
+
+// recordResult records a checker-level result, or the result of a target if target is not empty.
+func recordResult(ctx context.Context, checker Checker, target string, result *Result, err error) {
+	status, errorCode := resultLabels(result, err)
+	setRunStatus(ctx, status)
+	if target != "" {
+		setRunReportedTargets(ctx)
+	}
+	now := time.Now()
+	runID, duration := getRunInfo(ctx, now)
+	record := ResultRecord{
+		Cluster:     ClusterFromContext(ctx),
+		CheckerName: checker.Name(),
+		CheckerType: checker.Type(),
+		Target:      target,
+		Result:      result,
+		Err:         err,
+		Status:      status,
+		ErrorCode:   errorCode,
+		RunID:       runID,
+		Duration:    duration,
+		TraceID:     sampledTraceID(ctx),
+		Timestamp:   now,
+	}
 	if err != nil {
-		metrics.CheckerResultCounter.WithLabelValues(checkerType, checkerName, metrics.UnknownStatus, metrics.UnknownCode).Inc()
-		klog.V(3).InfoS("Recorded checker result", "name", checkerName, "type", checkerType, "status", metrics.UnknownStatus)
-		klog.ErrorS(err, "Failed checker run", "name", checkerName, "type", checkerType)
+		record.Message = err.Error()
+	} else {
+		record.Message = result.Detail.Message
+	}
+	applyEffectiveStatus(&record)
+	traceResult(ctx, record)
+	if target == "" {
+		latestResults.set(record)
+	} else {
+		evicted, stored := latestResults.setTarget(record, now.Add(-duration))
+		if evicted != "" {
+			forgetTarget(record.Cluster, checker, evicted)
+		}
+		if !stored {
+			effectiveStatuses.forgetTarget(record.trackerKey())
+			metrics.CheckerDroppedTargetResultCounter.WithLabelValues(record.Cluster, string(record.CheckerType), record.CheckerName).Inc()
+			klog.V(2).InfoS("Dropped checker target result, the checker reached its maximum number of targets",
+				"cluster", record.Cluster, "name", record.CheckerName, "type", record.CheckerType, "target", target)
+			return
+		}
+	}
+	addRunRecord(ctx, record)
+	writeToSinks(record)
+}
+
+// ForgetStaleTargets forgets the targets of a checker that have not recorded a result for longer than the target grace period of the
+// checker, e.g. CoreDNS pods that were replaced by a rollout: their latest results, tracked statuses and metric series are removed. It is
+// a no-op if the run did not record any target result or did not complete before ctx was done, so that targets are not forgotten while
+// the checker fails to list or reach them. ctx must be the context returned by WithRunStatus and passed to the checker's Run.
+func ForgetStaleTargets(ctx context.Context, checker Checker) {
+	forgetStaleTargets(ctx, checker, time.Now())
+}
+
+func forgetStaleTargets(ctx context.Context, checker Checker, now time.Time) {
+	if !getRunReportedTargets(ctx) || ctx.Err() != nil {
 		return
 	}
+	cluster := ClusterFromContext(ctx)
+	for _, target := range latestResults.staleTargets(checkerKey{cluster: cluster, name: checker.Name()}, now) {
+		forgetTarget(cluster, checker, target)
+	}
+}
 
-	// Record based on result status.
-	var status string
-	var errorCode string
-	switch result.Status {
-	case StatusHealthy:
-		status = metrics.HealthyStatus
-		errorCode = metrics.HealthyCode
-	case StatusUnhealthy:
-		status = metrics.UnhealthyStatus
-		errorCode = result.Detail.Code
+// forgetTarget removes the latest result, tracked statuses and metric series of a target of a checker of the named cluster.
+func forgetTarget(cluster string, checker Checker, target string) {
+	key := trackerKey{cluster: cluster, checkerName: checker.Name(), target: target}
+	latestResults.forgetTarget(key)
+	effectiveStatuses.forgetTarget(key)
+	for _, sink := range *resultSinks.Load() {
+		if cleaner, ok := sink.(TargetCleaner); ok {
+			cleaner.ForgetTarget(cluster, string(checker.Type()), checker.Name(), target)
+		}
 	}
+	klog.V(2).InfoS("Forgot checker target", "cluster", cluster, "name", checker.Name(), "type", checker.Type(), "target", target)
+}
 
-	metrics.CheckerResultCounter.WithLabelValues(checkerType, checkerName, status, errorCode).Inc()
-	klog.V(3).InfoS("Recorded checker result", "name", checkerName, "type", checkerType, "status", status, "errorCode", errorCode, "message", result.Detail.Message)
+// RecordRunDuration observes the duration of a checker run, labeled by the status recorded during the run. ctx must be the context
+// returned by WithRunStatus and passed to the checker's Run. Runs that did not record any result are labeled with the unknown status.
+// The trace ID of the run is attached as exemplar if the run is traced.
+func RecordRunDuration(ctx context.Context, checker Checker, duration time.Duration) {
+	observer := metrics.CheckerRunDuration.WithLabelValues(ClusterFromContext(ctx), string(checker.Type()), checker.Name(),
+		getRunStatus(ctx))
+	observeWithTraceID(observer, duration.Seconds(), sampledTraceID(ctx))
 }
 
-// RecordCoreDNSPodResult increments the result counter for a specific core DNS pod check.
-// If err is not nil, it records a run error (unknown status).
-// If result is not nil, it records the status from the result.
-func RecordCoreDNSPodResult(checker Checker, podName string, result *Result, err error) {
-	checkerType := string(checker.Type())
-	checkerName := checker.Name()
-	// If there's an error, record as unknown.
-	if err != nil {
-		metrics.CoreDNSPodResultCounter.WithLabelValues(checkerType, checkerName, podName, metrics.UnknownStatus, metrics.UnknownCode).Inc()
-		klog.V(3).InfoS("Recorded checker result", "name", checkerName, "type", checkerType, "podName", podName, "status", metrics.UnknownStatus)
-		klog.ErrorS(err, "Failed checker run", "name", checkerName, "type", checkerType, "podName", podName)
+// RecordRunFailure increments the run failure counter if a checker run exceeded its timeout, or otherwise if the checker returned an error
+// or did not record any result. ctx must be the context returned by WithRunStatus and passed to the checker's Run, checked before it is
+// canceled.
+func RecordRunFailure(ctx context.Context, checker Checker) {
+	var reason string
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		reason = metrics.RunFailureTimeout
+	case getRunStatus(ctx) == metrics.UnknownStatus:
+		reason = metrics.RunFailureError
+	default:
 		return
 	}
+	metrics.CheckerRunFailureCounter.WithLabelValues(ClusterFromContext(ctx), string(checker.Type()), checker.Name(), reason).Inc()
+}
+
+// RecordPanic records an unknown result with the Panic error code for a checker run that panicked with value, and increments the run
+// failure counter with the Panic reason instead of RecordRunFailure. ctx must be the context returned by WithRunStatus.
+func RecordPanic(ctx context.Context, checker Checker, value any) {
+	RecordResult(ctx, checker, nil, &PanicError{Value: value})
+	metrics.CheckerRunFailureCounter.WithLabelValues(ClusterFromContext(ctx), string(checker.Type()), checker.Name(),
+		metrics.RunFailurePanic).Inc()
+}
+
+// RecordMissedRun increments the missed run counter of a checker whose scheduled run did not start for the given reason. ctx is the
+// context the run would have been started with.
+func RecordMissedRun(ctx context.Context, checker Checker, reason string) {
+	cluster := ClusterFromContext(ctx)
+	metrics.CheckerMissedRunCounter.WithLabelValues(cluster, string(checker.Type()), checker.Name(), reason).Inc()
+	klog.V(2).InfoS("Missed checker run", "cluster", cluster, "name", checker.Name(), "type", checker.Type(), "reason", reason)
+}
+
+// RecordStepDuration observes the duration of a single step within a checker run, such as one API call or one DNS query. ctx is the
+// context of the run.
+func RecordStepDuration(ctx context.Context, checker Checker, step string, duration time.Duration) {
+	metrics.CheckerStepDuration.WithLabelValues(ClusterFromContext(ctx), string(checker.Type()), checker.Name(), step).
+		Observe(duration.Seconds())
+}
+
+// resultLabels returns the status and error code metric labels for a checker result.
+func resultLabels(result *Result, err error) (string, string) {
+	if IsPanic(err) {
+		return metrics.UnknownStatus, metrics.PanicCode
+	}
+	if err != nil {
+		return metrics.UnknownStatus, metrics.UnknownCode
+	}
 
-	// Record based on result status.
 	var status string
 	var errorCode string
 	switch result.Status {
@@ -97,8 +304,37 @@ func RecordCoreDNSPodResult(checker Checker, podName string, result *Result, err
 	case StatusUnhealthy:
 		status = metrics.UnhealthyStatus
 		errorCode = result.Detail.Code
+	case StatusSkipped:
+		status = metrics.SkippedStatus
+		errorCode = result.Detail.Code
+	}
+	return status, errorCode
+}
+
+// recordLatestStatus updates the status and timestamp gauges of a checker, or of a target if target is not empty, with a new result.
+// The last run timestamp of the checker is updated for target results as well, so that it goes stale whenever the checker stops running.
+func recordLatestStatus(cluster, checkerType, checkerName, target, status string, timestamp time.Time) {
+	metrics.CheckerLastRunTimestamp.WithLabelValues(cluster, checkerType, checkerName).Set(float64(timestamp.Unix()))
+	if target == "" {
+		setStatusGauge(metrics.CheckerStatusGauge, status, cluster, checkerType, checkerName)
+		if status == metrics.HealthyStatus {
+			metrics.CheckerLastSuccessTimestamp.WithLabelValues(cluster, checkerType, checkerName).Set(float64(timestamp.Unix()))
+		}
+		return
+	}
+	setStatusGauge(metrics.CheckerTargetStatusGauge, status, cluster, checkerType, checkerName, target)
+	if status == metrics.HealthyStatus {
+		metrics.CheckerTargetLastSuccessTimestamp.WithLabelValues(cluster, checkerType, checkerName, target).Set(float64(timestamp.Unix()))
 	}
+}
 
-	metrics.CoreDNSPodResultCounter.WithLabelValues(checkerType, checkerName, podName, status, errorCode).Inc()
-	klog.V(3).InfoS("Recorded checker result", "name", checkerName, "type", checkerType, "podName", podName, "status", status, "errorCode", errorCode, "message", result.Detail.Message)
+// setStatusGauge sets a gauge whose last label is the status to 1 for status and to 0 for all other statuses.
+func setStatusGauge(gauge *prometheus.GaugeVec, status string, labels ...string) {
+	for _, s := range allStatuses {
+		value := 0.0
+		if s == status {
+			value = 1
+		}
+		gauge.WithLabelValues(append(labels, s)...).Set(value)
+	}
 }
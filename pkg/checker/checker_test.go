@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
 	. "github.com/onsi/gomega"
-	"k8s.io/client-go/kubernetes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 )
 
@@ -17,10 +19,13 @@ func (f *fakeChecker) Name() string             { return f.name }
 func (f *fakeChecker) Run(ctx context.Context)  {}
 func (f *fakeChecker) Type() config.CheckerType { return config.CheckerType("fake") }
 
-func fakeBuilder(cfg *config.CheckerConfig, kubeClient kubernetes.Interface) (Checker, error) {
+func fakeBuilder(cfg *config.CheckerConfig, cluster *Cluster) (Checker, error) {
 	if cfg.Name == "fail" {
 		return nil, errors.New("forced error")
 	}
+	if cfg.Name == "skip" {
+		return nil, ErrSkipChecker
+	}
 	return &fakeChecker{name: cfg.Name}, nil
 }
 
@@ -29,7 +34,7 @@ func TestBuildChecker(t *testing.T) {
 	testCases := []struct {
 		name            string
 		config          *config.CheckerConfig
-		kubeClient      kubernetes.Interface
+		cluster         *Cluster
 		validateChecker func(g *WithT, chk Checker, err error)
 	}{
 		{
@@ -39,7 +44,7 @@ func TestBuildChecker(t *testing.T) {
 				RegisterChecker(testType, fakeBuilder)
 				return &config.CheckerConfig{Name: "foo", Type: testType}
 			}(),
-			kubeClient: k8sfake.NewClientset(),
+			cluster: &Cluster{KubeClient: k8sfake.NewClientset()},
 			validateChecker: func(g *WithT, chk Checker, err error) {
 				g.Expect(err).ToNot(HaveOccurred())
 				g.Expect(chk).ToNot(BeNil())
@@ -47,9 +52,9 @@ func TestBuildChecker(t *testing.T) {
 			},
 		},
 		{
-			name:       "Build checker with unknown type",
-			config:     &config.CheckerConfig{Name: "bar", Type: "unknown"},
-			kubeClient: k8sfake.NewClientset(),
+			name:    "Build checker with unknown type",
+			config:  &config.CheckerConfig{Name: "bar", Type: "unknown"},
+			cluster: &Cluster{KubeClient: k8sfake.NewClientset()},
 			validateChecker: func(g *WithT, chk Checker, err error) {
 				g.Expect(err).To(HaveOccurred())
 				g.Expect(chk).To(BeNil())
@@ -62,7 +67,7 @@ func TestBuildChecker(t *testing.T) {
 				RegisterChecker(testType, fakeBuilder)
 				return &config.CheckerConfig{Name: "fail", Type: testType}
 			}(),
-			kubeClient: k8sfake.NewClientset(),
+			cluster: &Cluster{KubeClient: k8sfake.NewClientset()},
 			validateChecker: func(g *WithT, chk Checker, err error) {
 				g.Expect(err).To(HaveOccurred())
 				g.Expect(chk).To(BeNil())
@@ -75,7 +80,7 @@ func TestBuildChecker(t *testing.T) {
 				RegisterChecker(testType, fakeBuilder)
 				return &config.CheckerConfig{Name: "foo", Type: testType}
 			}(),
-			kubeClient: nil,
+			cluster: &Cluster{},
 			validateChecker: func(g *WithT, chk Checker, err error) {
 				g.Expect(err).To(HaveOccurred())
 				g.Expect(chk).To(BeNil())
@@ -88,8 +93,319 @@ func TestBuildChecker(t *testing.T) {
 			t.Parallel()
 			g := NewWithT(t)
 
-			chk, err := Build(tc.config, tc.kubeClient)
+			chk, _, err := Build(tc.config, tc.cluster)
 			tc.validateChecker(g, chk, err)
 		})
 	}
 }
+
+func TestBuildChecker_Configure(t *testing.T) {
+	g := NewWithT(t)
+	testType := config.CheckerType("fake")
+	RegisterChecker(testType, fakeBuilder)
+	cluster := &Cluster{Name: "configure", KubeClient: k8sfake.NewClientset()}
+
+	_, configure, err := Build(&config.CheckerConfig{Name: "skip", Type: testType}, cluster)
+	g.Expect(err).To(MatchError(ErrSkipChecker))
+	g.Expect(metrics.CheckerSkippedGauge.DeleteLabelValues("configure", string(testType), "skip")).To(BeFalse(), "build does not record the skipped checker")
+	configure()
+	g.Expect(metrics.CheckerSkippedGauge.DeleteLabelValues("configure", string(testType), "skip")).To(BeTrue())
+
+	cfg := &config.CheckerConfig{Name: "thresholds", Type: testType, FailureThreshold: 3}
+	defer ClearChecker(cluster.Name, cfg)
+	_, configure, err = Build(cfg, cluster)
+	g.Expect(err).ToNot(HaveOccurred())
+	key := checkerKey{cluster: cluster.Name, name: cfg.Name}
+	effectiveStatuses.mu.Lock()
+	_, configured := effectiveStatuses.thresholds[key]
+	effectiveStatuses.mu.Unlock()
+	g.Expect(configured).To(BeFalse(), "build does not apply the thresholds")
+	configure()
+	effectiveStatuses.mu.Lock()
+	g.Expect(effectiveStatuses.thresholds[key].failure).To(Equal(3))
+	effectiveStatuses.mu.Unlock()
+}
+
+func TestRunStatus(t *testing.T) {
+	t.Parallel()
+	chk := &fakeChecker{name: "run-status"}
+	testCases := []struct {
+		name           string
+		record         func(ctx context.Context)
+		expectedStatus string
+	}{
+		{
+			name:           "No result recorded",
+			record:         func(ctx context.Context) {},
+			expectedStatus: metrics.UnknownStatus,
+		},
+		{
+			name: "Healthy result",
+			record: func(ctx context.Context) {
+				RecordResult(ctx, chk, Healthy(), nil)
+			},
+			expectedStatus: metrics.HealthyStatus,
+		},
+		{
+			name: "Run error",
+			record: func(ctx context.Context) {
+				RecordResult(ctx, chk, nil, errors.New("run error"))
+			},
+			expectedStatus: metrics.UnknownStatus,
+		},
+		{
+			name: "First non-healthy target result is kept",
+			record: func(ctx context.Context) {
+				RecordTargetResult(ctx, chk, "pod1", Healthy(), nil)
+				RecordTargetResult(ctx, chk, "pod2", Unhealthy("code", "message"), nil)
+				RecordTargetResult(ctx, chk, "pod3", nil, errors.New("run error"))
+				RecordTargetResult(ctx, chk, "pod4", Healthy(), nil)
+			},
+			expectedStatus: metrics.UnhealthyStatus,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			ctx := WithRunStatus(context.Background())
+			tc.record(ctx)
+			g.Expect(getRunStatus(ctx)).To(Equal(tc.expectedStatus))
+		})
+	}
+}
+
+func TestResultLabels(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name           string
+		result         *Result
+		err            error
+		expectedStatus string
+		expectedCode   string
+	}{
+		{
+			name:           "Healthy result",
+			result:         Healthy(),
+			expectedStatus: metrics.HealthyStatus,
+			expectedCode:   metrics.HealthyCode,
+		},
+		{
+			name:           "Unhealthy result",
+			result:         Unhealthy("SomeError", "message"),
+			expectedStatus: metrics.UnhealthyStatus,
+			expectedCode:   "SomeError",
+		},
+		{
+			name:           "Skipped result",
+			result:         Skipped("SomeReason", "message"),
+			expectedStatus: metrics.SkippedStatus,
+			expectedCode:   "SomeReason",
+		},
+		{
+			name:           "Run error",
+			err:            errors.New("run error"),
+			expectedStatus: metrics.UnknownStatus,
+			expectedCode:   metrics.UnknownCode,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+
+			status, code := resultLabels(tc.result, tc.err)
+			g.Expect(status).To(Equal(tc.expectedStatus))
+			g.Expect(code).To(Equal(tc.expectedCode))
+		})
+	}
+}
+
+func TestRecordLatestStatus(t *testing.T) {
+	g := NewWithT(t)
+	const checkerType, checkerName = "fake", "latest-status"
+	start := time.Unix(1000, 0)
+
+	recordLatestStatus("", checkerType, checkerName, "", metrics.HealthyStatus, start)
+	recordLatestStatus("", checkerType, checkerName, "", metrics.UnhealthyStatus, start.Add(time.Minute))
+	g.Expect(testutil.ToFloat64(metrics.CheckerStatusGauge.WithLabelValues("", checkerType, checkerName, metrics.UnhealthyStatus))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerStatusGauge.WithLabelValues("", checkerType, checkerName, metrics.HealthyStatus))).To(Equal(0.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerLastRunTimestamp.WithLabelValues("", checkerType, checkerName))).To(Equal(1060.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerLastSuccessTimestamp.WithLabelValues("", checkerType, checkerName))).To(Equal(1000.0))
+
+	recordLatestStatus("", checkerType, checkerName, "coredns-1", metrics.HealthyStatus, start.Add(2*time.Minute))
+	g.Expect(testutil.ToFloat64(metrics.CheckerTargetStatusGauge.WithLabelValues("", checkerType, checkerName, "coredns-1", metrics.HealthyStatus))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerTargetLastSuccessTimestamp.WithLabelValues("", checkerType, checkerName, "coredns-1"))).To(Equal(1120.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerLastRunTimestamp.WithLabelValues("", checkerType, checkerName))).To(Equal(1120.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerLastSuccessTimestamp.WithLabelValues("", checkerType, checkerName))).To(Equal(1000.0))
+
+	ClearChecker("", &config.CheckerConfig{Name: checkerName, Type: checkerType})
+	g.Expect(metrics.CheckerStatusGauge.DeleteLabelValues("", checkerType, checkerName, metrics.HealthyStatus)).To(BeFalse())
+	g.Expect(metrics.CheckerTargetStatusGauge.DeleteLabelValues("", checkerType, checkerName, "coredns-1", metrics.HealthyStatus)).To(BeFalse())
+	g.Expect(metrics.CheckerLastRunTimestamp.DeleteLabelValues("", checkerType, checkerName)).To(BeFalse())
+}
+
+func TestForgetStaleTargets(t *testing.T) {
+	g := NewWithT(t)
+	chk := &fakeChecker{name: "stale-targets"}
+	latestResults.configure(checkerKey{name: chk.name}, &config.CheckerConfig{Name: chk.name, TargetGracePeriod: time.Minute})
+	defer latestResults.forget(checkerKey{name: chk.name})
+	targetSeries := func(target string) bool {
+		return metrics.CheckerTargetStatusGauge.DeleteLabelValues("", "fake", chk.name, target, metrics.UnknownStatus)
+	}
+
+	ctx := WithRunStatus(context.Background())
+	RecordTargetResult(ctx, chk, "coredns-1", Healthy(), nil)
+	RecordTargetResult(ctx, chk, "coredns-2", Healthy(), nil)
+	forgetStaleTargets(ctx, chk, time.Now())
+	g.Expect(LatestTargetResults("", chk.name)).To(HaveLen(2))
+
+	// A target that is no longer reported is kept within the grace period.
+	ctx = WithRunStatus(context.Background())
+	RecordTargetResult(ctx, chk, "coredns-2", Healthy(), nil)
+	forgetStaleTargets(ctx, chk, time.Now().Add(30*time.Second))
+	g.Expect(LatestTargetResults("", chk.name)).To(HaveLen(2))
+
+	// A run that did not record any target result, e.g. because listing the targets failed, keeps all targets.
+	forgetStaleTargets(WithRunStatus(context.Background()), chk, time.Now().Add(2*time.Minute))
+	g.Expect(LatestTargetResults("", chk.name)).To(HaveLen(2))
+
+	// A target that has not been reported for longer than the grace period is forgotten.
+	ctx = WithRunStatus(context.Background())
+	RecordTargetResult(ctx, chk, "coredns-2", Healthy(), nil)
+	RecordTargetResult(ctx, chk, "coredns-3", Healthy(), nil)
+	latestResults.targets[checkerKey{name: chk.name}]["coredns-1"] = ResultRecord{CheckerName: chk.name, Target: "coredns-1", Timestamp: time.Now().Add(-2 * time.Minute)}
+	forgetStaleTargets(ctx, chk, time.Now())
+	records := LatestTargetResults("", chk.name)
+	g.Expect(records).To(HaveLen(2))
+	g.Expect(records[0].Target).To(Equal("coredns-2"))
+	g.Expect(records[1].Target).To(Equal("coredns-3"))
+	g.Expect(targetSeries("coredns-1")).To(BeFalse(), "the series of the forgotten target are deleted")
+	g.Expect(targetSeries("coredns-2")).To(BeTrue())
+	g.Expect(metrics.CheckerTargetResultCounter.DeleteLabelValues("", "fake", chk.name, "coredns-1", metrics.HealthyStatus,
+		metrics.HealthyCode)).To(BeFalse())
+}
+
+func TestMaxTargets(t *testing.T) {
+	g := NewWithT(t)
+	chk := &fakeChecker{name: "max-targets"}
+	cfg := &config.CheckerConfig{Name: chk.name, Type: chk.Type(), MaxTargets: 2}
+	latestResults.configure(checkerKey{name: chk.name}, cfg)
+	t.Cleanup(func() { ClearChecker("", cfg) })
+	// The counter is not cleared with the checker, so only the targets dropped by this test are counted.
+	droppedBefore := testutil.ToFloat64(metrics.CheckerDroppedTargetResultCounter.WithLabelValues("", "fake", chk.name))
+	dropped := func() float64 {
+		return testutil.ToFloat64(metrics.CheckerDroppedTargetResultCounter.WithLabelValues("", "fake", chk.name)) - droppedBefore
+	}
+	targets := func() []string {
+		var names []string
+		for _, record := range LatestTargetResults("", chk.name) {
+			names = append(names, record.Target)
+		}
+		return names
+	}
+
+	// Targets beyond the maximum are dropped within the same run.
+	ctx := WithRunStatus(context.Background())
+	RecordTargetResult(ctx, chk, "coredns-1", Healthy(), nil)
+	RecordTargetResult(ctx, chk, "coredns-2", Healthy(), nil)
+	RecordTargetResult(ctx, chk, "coredns-3", Healthy(), nil)
+	g.Expect(targets()).To(Equal([]string{"coredns-1", "coredns-2"}))
+	g.Expect(dropped()).To(Equal(1.0))
+
+	// In a later run, a new target replaces the target that was reported least recently.
+	time.Sleep(time.Millisecond)
+	ctx = WithRunStatus(context.Background())
+	RecordTargetResult(ctx, chk, "coredns-2", Healthy(), nil)
+	RecordTargetResult(ctx, chk, "coredns-3", Healthy(), nil)
+	g.Expect(targets()).To(Equal([]string{"coredns-2", "coredns-3"}))
+	g.Expect(dropped()).To(Equal(1.0))
+	g.Expect(metrics.CheckerTargetStatusGauge.DeleteLabelValues("", "fake", chk.name, "coredns-1", metrics.HealthyStatus)).To(BeFalse())
+}
+
+func TestRecordRunFailure(t *testing.T) {
+	g := NewWithT(t)
+	chk := &fakeChecker{name: "run-failure"}
+	t.Cleanup(func() { ClearChecker("", &config.CheckerConfig{Name: chk.name, Type: chk.Type()}) })
+	// The counters are not cleared with the checker, so only the failures and results recorded by this test are counted.
+	failure := func(reason string) float64 {
+		return testutil.ToFloat64(metrics.CheckerRunFailureCounter.WithLabelValues("", "fake", chk.name, reason))
+	}
+	panicResult := func() float64 {
+		return testutil.ToFloat64(metrics.CheckerResultCounter.WithLabelValues("", "fake", chk.name, metrics.UnknownStatus, metrics.PanicCode))
+	}
+	failuresBefore := map[string]float64{
+		metrics.RunFailureTimeout: failure(metrics.RunFailureTimeout),
+		metrics.RunFailureError:   failure(metrics.RunFailureError),
+		metrics.RunFailurePanic:   failure(metrics.RunFailurePanic),
+	}
+	panicResultsBefore := panicResult()
+	failures := func(reason string) float64 {
+		return failure(reason) - failuresBefore[reason]
+	}
+
+	// A healthy run is not a failure.
+	ctx := WithRunStatus(context.Background())
+	RecordResult(ctx, chk, Healthy(), nil)
+	RecordRunFailure(ctx, chk)
+	g.Expect(failures(metrics.RunFailureTimeout)).To(BeZero())
+	g.Expect(failures(metrics.RunFailureError)).To(BeZero())
+
+	// A run in which the checker returned an error.
+	ctx = WithRunStatus(context.Background())
+	RecordResult(ctx, chk, nil, errors.New("boom"))
+	RecordRunFailure(ctx, chk)
+	g.Expect(failures(metrics.RunFailureError)).To(Equal(1.0))
+
+	// A run that exceeded its timeout is a timeout even if the checker returned an error.
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	ctx = WithRunStatus(ctx)
+	RecordResult(ctx, chk, nil, context.DeadlineExceeded)
+	RecordRunFailure(ctx, chk)
+	g.Expect(failures(metrics.RunFailureTimeout)).To(Equal(1.0))
+	g.Expect(failures(metrics.RunFailureError)).To(Equal(1.0))
+
+	// A run that panicked is recorded as an unknown result with the Panic error code.
+	ctx = WithRunStatus(context.Background())
+	RecordPanic(ctx, chk, "boom")
+	g.Expect(failures(metrics.RunFailurePanic)).To(Equal(1.0))
+	g.Expect(panicResult() - panicResultsBefore).To(Equal(1.0))
+	g.Expect(getRunStatus(ctx)).To(Equal(metrics.UnknownStatus))
+}
+
+func TestRecordResult_Clusters(t *testing.T) {
+	g := NewWithT(t)
+	chk := &fakeChecker{name: "clusters"}
+	cfg := &config.CheckerConfig{Name: chk.name, Type: chk.Type()}
+	defer ClearChecker("cluster-a", cfg)
+	defer ClearChecker("cluster-b", cfg)
+
+	RecordResult(WithCluster(WithRunStatus(context.Background()), "cluster-a"), chk, Healthy(), nil)
+	RecordResult(WithCluster(WithRunStatus(context.Background()), "cluster-b"), chk, Unhealthy("CODE", "boom"), nil)
+
+	// Checkers with the same name in different clusters keep separate results and series.
+	a, ok := LatestResult("cluster-a", chk.name)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(a.Cluster).To(Equal("cluster-a"))
+	g.Expect(a.Status).To(Equal(metrics.HealthyStatus))
+	b, ok := LatestResult("cluster-b", chk.name)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(b.Status).To(Equal(metrics.UnhealthyStatus))
+	_, ok = LatestResult("", chk.name)
+	g.Expect(ok).To(BeFalse())
+	g.Expect(testutil.ToFloat64(metrics.CheckerStatusGauge.WithLabelValues("cluster-a", "fake", chk.name, metrics.HealthyStatus))).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerStatusGauge.WithLabelValues("cluster-b", "fake", chk.name, metrics.UnhealthyStatus))).To(Equal(1.0))
+
+	// Clearing the checker of one cluster keeps the other.
+	ClearChecker("cluster-a", cfg)
+	_, ok = LatestResult("cluster-a", chk.name)
+	g.Expect(ok).To(BeFalse())
+	_, ok = LatestResult("cluster-b", chk.name)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(metrics.CheckerStatusGauge.DeleteLabelValues("cluster-a", "fake", chk.name, metrics.HealthyStatus)).To(BeFalse())
+	g.Expect(metrics.CheckerStatusGauge.DeleteLabelValues("cluster-b", "fake", chk.name, metrics.HealthyStatus)).To(BeTrue())
+}
@@ -0,0 +1,68 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Cluster is a cluster checked by checkers. It is passed to the checker builders, so that all checkers of a cluster share the same
+// config and Kubernetes client.
+type Cluster struct {
+	// Name identifies the cluster in the metrics, results and events of its checkers. It is the kubeconfig context of the cluster when
+	// the monitor checks several clusters, and empty otherwise.
+	Name string
+	// RESTConfig is the config used to connect to the cluster. Checkers that need a client other than KubeClient, e.g. a dynamic client,
+	// create it from RESTConfig.
+	RESTConfig *rest.Config
+	// KubeClient is the Kubernetes client of the cluster.
+	KubeClient kubernetes.Interface
+}
+
+// NewCluster creates a Cluster and its Kubernetes client from restConfig.
+func NewCluster(name string, restConfig *rest.Config) (*Cluster, error) {
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return &Cluster{Name: name, RESTConfig: restConfig, KubeClient: kubeClient}, nil
+}
+
+// LoadRESTConfig returns the config used to connect to a cluster. When kubeconfig and kubeContext are both empty, which is the default,
+// the in-cluster config is used. Otherwise the config is loaded from the kubeconfig file, or from the default kubeconfig loading rules
+// if kubeconfig is empty, using kubeContext instead of the current context if it is set.
+func LoadRESTConfig(kubeconfig, kubeContext string) (*rest.Config, error) {
+	if kubeconfig == "" && kubeContext == "" {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+		}
+		return restConfig, nil
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig %q with context %q: %w", kubeconfig, kubeContext, err)
+	}
+	return restConfig, nil
+}
+
+// clusterKey is the context key under which the name of the cluster checked by a checker run is stored.
+type clusterKey struct{}
+
+// WithCluster returns a copy of ctx for the runs of the checkers of the named cluster. The results and metrics recorded with the context
+// are labeled with the cluster.
+func WithCluster(ctx context.Context, cluster string) context.Context {
+	return context.WithValue(ctx, clusterKey{}, cluster)
+}
+
+// ClusterFromContext returns the name of the cluster set by WithCluster, or an empty name if it is not set.
+func ClusterFromContext(ctx context.Context) string {
+	cluster, _ := ctx.Value(clusterKey{}).(string)
+	return cluster
+}
@@ -0,0 +1,51 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: a
+  cluster:
+    server: https://a.example.com
+- name: b
+  cluster:
+    server: https://b.example.com
+users:
+- name: user
+  user:
+    token: token
+contexts:
+- name: a
+  context:
+    cluster: a
+    user: user
+- name: b
+  context:
+    cluster: b
+    user: user
+current-context: a
+`
+
+func TestLoadRESTConfig(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	g.Expect(os.WriteFile(path, []byte(testKubeconfig), 0o600)).To(Succeed())
+
+	restConfig, err := LoadRESTConfig(path, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(restConfig.Host).To(Equal("https://a.example.com"), "the current context is used by default")
+
+	restConfig, err = LoadRESTConfig(path, "b")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(restConfig.Host).To(Equal("https://b.example.com"))
+
+	_, err = LoadRESTConfig(path, "missing")
+	g.Expect(err).To(HaveOccurred())
+}
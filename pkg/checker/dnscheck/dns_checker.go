@@ -5,8 +5,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/miekg/dns"
+	"go.opentelemetry.io/otel/attribute"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,6 +30,17 @@ const (
 	localDNSIP         = "169.254.10.11"
 )
 
+const (
+	// These are the steps of the DNSChecker's run whose durations are recorded.
+	stepServiceQuery  = "ServiceQuery"
+	stepPodQuery      = "PodQuery"
+	stepLocalDNSQuery = "LocalDNSQuery"
+
+	// These are the additional steps of the DNSChecker's run that are traced.
+	stepGetService    = "GetCoreDNSService"
+	stepListEndpoints = "ListCoreDNSEndpoints"
+)
+
 // DNSChecker implements the Checker interface for DNS checks.
 type DNSChecker struct {
 	name       string
@@ -38,7 +51,7 @@ type DNSChecker struct {
 
 // BuildDNSChecker creates a new DNSChecker instance.
 // If the DNSType is LocalDNS, it checks if LocalDNS IP is enabled before creating the checker.
-func BuildDNSChecker(checkerConfig *config.CheckerConfig, kubeClient kubernetes.Interface) (checker.Checker, error) {
+func BuildDNSChecker(checkerConfig *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
 	// If this is a LocalDNS checker, check if LocalDNS IP is enabled.
 	switch checkerConfig.DNSConfig.Target {
 	case config.DNSCheckTargetLocalDNS:
@@ -56,7 +69,7 @@ func BuildDNSChecker(checkerConfig *config.CheckerConfig, kubeClient kubernetes.
 	chk := &DNSChecker{
 		name:       checkerConfig.Name,
 		config:     checkerConfig.DNSConfig,
-		kubeClient: kubeClient,
+		kubeClient: cluster.KubeClient,
 		resolver:   &defaultResolver{},
 	}
 	klog.InfoS("Built DNSChecker",
@@ -74,15 +87,20 @@ func (c DNSChecker) Type() config.CheckerType {
 	return config.CheckTypeDNS
 }
 
+// ReadOnly returns true as the DNS checker only sends DNS queries and lists CoreDNS endpoints.
+func (c DNSChecker) ReadOnly() bool {
+	return true
+}
+
 func (c DNSChecker) Run(ctx context.Context) {
 	switch c.config.Target {
 	case config.DNSCheckTargetCoreDNS:
 		result, err := c.checkCoreDNS(ctx)
-		checker.RecordResult(c, result, err)
+		checker.RecordResult(ctx, c, result, err)
 		return
 	case config.DNSCheckTargetLocalDNS:
 		result, err := c.checkLocalDNS(ctx)
-		checker.RecordResult(c, result, err)
+		checker.RecordResult(ctx, c, result, err)
 		return
 	case config.DNSCheckTargetCoreDNSPerPod:
 		c.checkCoreDNSPerPod(ctx)
@@ -100,7 +118,7 @@ func (c DNSChecker) checkCoreDNS(ctx context.Context) (*checker.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	if _, err := c.resolver.lookupHost(ctx, svcIP, c.config.Domain, c.config.QueryTimeout); err != nil {
+	if _, err := c.lookupHost(ctx, stepServiceQuery, svcIP); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodeServiceTimeout, "CoreDNS service query timed out"), nil
 		}
@@ -118,7 +136,7 @@ func (c DNSChecker) checkCoreDNS(ctx context.Context) (*checker.Result, error) {
 
 	for _, dnsEndpoint := range dnsEndpoints {
 		for _, ip := range dnsEndpoint.Addresses {
-			if _, err := c.resolver.lookupHost(ctx, ip, c.config.Domain, c.config.QueryTimeout); err != nil {
+			if _, err := c.lookupHost(ctx, stepPodQuery, ip); err != nil {
 				if errors.Is(err, context.DeadlineExceeded) {
 					return checker.Unhealthy(ErrCodePodTimeout, "CoreDNS pod query timed out"), nil
 				}
@@ -133,7 +151,7 @@ func (c DNSChecker) checkCoreDNS(ctx context.Context) (*checker.Result, error) {
 // checkLocalDNS queries the LocalDNS server.
 // If the query succeeds, the check is considered healthy.
 func (c DNSChecker) checkLocalDNS(ctx context.Context) (*checker.Result, error) {
-	if _, err := c.resolver.lookupHost(ctx, localDNSIP, c.config.Domain, c.config.QueryTimeout); err != nil {
+	if _, err := c.lookupHost(ctx, stepLocalDNSQuery, localDNSIP); err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodeLocalDNSTimeout, "LocalDNS query timed out"), nil
 		}
@@ -168,27 +186,41 @@ func (c DNSChecker) checkCoreDNSPerPod(ctx context.Context) {
 		err := c.queryEndpoint(ctx, endpoint)
 		if err != nil {
 			if errors.Is(err, context.DeadlineExceeded) {
-				checker.RecordCoreDNSPodResult(c, podname, checker.Unhealthy(ErrCodePodTimeout, "CoreDNS pod query timed out"), nil)
+				checker.RecordTargetResult(ctx, c, podname, checker.Unhealthy(ErrCodePodTimeout, "CoreDNS pod query timed out"), nil)
 			} else {
-				checker.RecordCoreDNSPodResult(c, podname, nil, err)
+				checker.RecordTargetResult(ctx, c, podname, nil, err)
 			}
 		} else {
-			checker.RecordCoreDNSPodResult(c, podname, checker.Healthy(), nil)
+			checker.RecordTargetResult(ctx, c, podname, checker.Healthy(), nil)
 		}
 	}
 }
 
 func (c DNSChecker) queryEndpoint(ctx context.Context, endpoint discoveryv1.Endpoint) error {
 	for _, ip := range endpoint.Addresses {
-		if _, err := c.resolver.lookupHost(ctx, ip, c.config.Domain, c.config.QueryTimeout); err != nil {
+		if _, err := c.lookupHost(ctx, stepPodQuery, ip); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// lookupHost queries the configured domain against the DNS server at dnsIP and records the query duration as the given step.
+func (c DNSChecker) lookupHost(ctx context.Context, step, dnsIP string) (addrs []string, err error) {
+	ctx, span := checker.StartSpan(ctx, step, attribute.String("dns.server", dnsIP), attribute.String("dns.domain", c.config.Domain))
+	start := time.Now()
+	defer func() {
+		checker.RecordStepDuration(ctx, c, step, time.Since(start))
+		checker.EndSpan(span, err)
+	}()
+	return c.resolver.lookupHost(ctx, dnsIP, c.config.Domain, c.config.QueryTimeout)
+}
+
 // getCoreDNSSvcIP returns the ClusterIP of the CoreDNS service in the cluster as a DNSTarget.
-func getCoreDNSSvcIP(ctx context.Context, kubeClient kubernetes.Interface) (string, error) {
+func getCoreDNSSvcIP(ctx context.Context, kubeClient kubernetes.Interface) (_ string, err error) {
+	ctx, span := checker.StartSpan(ctx, stepGetService)
+	defer func() { checker.EndSpan(span, err) }()
+
 	svc, err := kubeClient.CoreV1().Services(coreDNSNamespace).Get(ctx, coreDNSServiceName, metav1.GetOptions{})
 
 	if err != nil && apierrors.IsNotFound(err) {
@@ -206,7 +238,10 @@ func getCoreDNSSvcIP(ctx context.Context, kubeClient kubernetes.Interface) (stri
 }
 
 // getCoreDNSEndpoints returns all CoreDNS pod endpoints in the cluster.
-func getCoreDNSEndpoints(ctx context.Context, kubeClient kubernetes.Interface) ([]discoveryv1.Endpoint, error) {
+func getCoreDNSEndpoints(ctx context.Context, kubeClient kubernetes.Interface) (_ []discoveryv1.Endpoint, err error) {
+	ctx, span := checker.StartSpan(ctx, stepListEndpoints)
+	defer func() { checker.EndSpan(span, err) }()
+
 	endpointSliceList, err := kubeClient.DiscoveryV1().EndpointSlices(coreDNSNamespace).List(ctx, metav1.ListOptions{
 		LabelSelector: discoveryv1.LabelServiceName + "=" + coreDNSServiceName,
 	})
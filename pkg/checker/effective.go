@@ -0,0 +1,175 @@
+package checker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+)
+
+// statusThresholds are the settings used to derive the effective status of a checker from its results.
+type statusThresholds struct {
+	failure       int
+	success       int
+	flapDetection *config.FlapDetectionConfig
+}
+
+// effectiveState is the effective status state of a checker, or of a single target of a per-target checker.
+type effectiveState struct {
+	// status is the effective status. It is empty while it is pending.
+	status string
+	// streak is the number of consecutive results that disagree with the effective status, or the number of failing results while the
+	// effective status is pending.
+	streak int
+	// lastStatus is the status of the latest result.
+	lastStatus string
+	// changes holds the times at which the result status changed within the flap detection window.
+	changes []time.Time
+}
+
+// effectiveStatusStore derives the effective status of checkers from their results. The effective status only changes from passing
+// (healthy or skipped) to failing (unhealthy or unknown) after FailureThreshold consecutive failing results, and back after
+// SuccessThreshold consecutive passing results. A change within the same class, e.g. from unhealthy to unknown, is applied immediately.
+// The first passing result sets the effective status right away, whereas failing first results leave it pending until FailureThreshold
+// of them are recorded, so that a single failure after the checker was started is not reported.
+type effectiveStatusStore struct {
+	mu         sync.Mutex
+	thresholds map[checkerKey]statusThresholds
+	states     map[trackerKey]*effectiveState
+}
+
+var effectiveStatuses = &effectiveStatusStore{
+	thresholds: make(map[checkerKey]statusThresholds),
+	states:     make(map[trackerKey]*effectiveState),
+}
+
+// configure sets the thresholds of a checker. The effective status of the checker is kept, but pending streaks start over.
+func (s *effectiveStatusStore) configure(chkKey checkerKey, cfg *config.CheckerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.thresholds[chkKey] = statusThresholds{
+		failure:       max(cfg.FailureThreshold, 1),
+		success:       max(cfg.SuccessThreshold, 1),
+		flapDetection: cfg.FlapDetection,
+	}
+	for key, state := range s.states {
+		if key.checkerKey() == chkKey {
+			state.streak = 0
+		}
+	}
+}
+
+// forget removes the thresholds and state of a checker.
+func (s *effectiveStatusStore) forget(chkKey checkerKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.thresholds, chkKey)
+	for key := range s.states {
+		if key.checkerKey() == chkKey {
+			delete(s.states, key)
+		}
+	}
+}
+
+// forgetTarget removes the state of a target of a checker.
+func (s *effectiveStatusStore) forgetTarget(key trackerKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, key)
+}
+
+// observe applies a result status to the state of a checker, or of a target of a per-target checker, and returns the effective status, the
+// previous effective status and whether the result status is flapping. effective is empty while it is pending, and previous is empty
+// for the first result. flapping is always false if flap detection is not configured for the checker.
+func (s *effectiveStatusStore) observe(key trackerKey, status string, now time.Time) (effective, previous string, flapping, flapDetection bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	thresholds, ok := s.thresholds[key.checkerKey()]
+	if !ok {
+		thresholds = statusThresholds{failure: 1, success: 1}
+	}
+
+	state, ok := s.states[key]
+	if ok {
+		previous = state.status
+	} else {
+		state = &effectiveState{lastStatus: status}
+		s.states[key] = state
+	}
+
+	if status != state.lastStatus {
+		state.changes = append(state.changes, now)
+		state.lastStatus = status
+	}
+
+	failing := isFailingStatus(status)
+	if state.status == "" {
+		// The effective status is pending, there is no previous effective status to compare the result with.
+		state.streak++
+		if !failing || state.streak >= thresholds.failure {
+			state.status = status
+			state.streak = 0
+		}
+	} else if failing == isFailingStatus(state.status) {
+		state.status = status
+		state.streak = 0
+	} else {
+		state.streak++
+		threshold := thresholds.success
+		if failing {
+			threshold = thresholds.failure
+		}
+		if state.streak >= threshold {
+			state.status = status
+			state.streak = 0
+		}
+	}
+
+	if thresholds.flapDetection == nil {
+		state.changes = nil
+		return state.status, previous, false, false
+	}
+	cutoff := now.Add(-thresholds.flapDetection.Window)
+	for len(state.changes) > 0 && !state.changes[0].After(cutoff) {
+		state.changes = state.changes[1:]
+	}
+	return state.status, previous, len(state.changes) > thresholds.flapDetection.MaxTransitions, true
+}
+
+// isFailingStatus returns whether a status label counts against the failure threshold.
+func isFailingStatus(status string) bool {
+	return status == metrics.UnhealthyStatus || status == metrics.UnknownStatus
+}
+
+// applyEffectiveStatus sets the effective status fields of a result record from the status of the result.
+func applyEffectiveStatus(record *ResultRecord) {
+	effective, previous, flapping, flapDetection := effectiveStatuses.observe(record.trackerKey(), record.Status, record.Timestamp)
+	record.EffectiveStatus = effective
+	record.PreviousEffectiveStatus = previous
+	if flapDetection {
+		record.Flapping = &flapping
+	}
+}
+
+// recordEffectiveStatus updates the effective status and flapping gauges of a checker, or of a target if target is not empty. All effective
+// status gauges are 0 while the effective status is pending. flapping is nil if flap detection is not configured for the checker.
+func recordEffectiveStatus(cluster, checkerType, checkerName, target, effective string, flapping *bool) {
+	if target == "" {
+		setStatusGauge(metrics.CheckerEffectiveStatusGauge, effective, cluster, checkerType, checkerName)
+	} else {
+		setStatusGauge(metrics.CheckerTargetEffectiveStatusGauge, effective, cluster, checkerType, checkerName, target)
+	}
+	if flapping == nil {
+		return
+	}
+	value := 0.0
+	if *flapping {
+		value = 1
+	}
+	if target == "" {
+		metrics.CheckerFlappingGauge.WithLabelValues(cluster, checkerType, checkerName).Set(value)
+	} else {
+		metrics.CheckerTargetFlappingGauge.WithLabelValues(cluster, checkerType, checkerName, target).Set(value)
+	}
+}
@@ -0,0 +1,118 @@
+package checker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	. "github.com/onsi/gomega"
+)
+
+func TestEffectiveStatusStore_Observe(t *testing.T) {
+	t.Parallel()
+	const (
+		healthy   = metrics.HealthyStatus
+		unhealthy = metrics.UnhealthyStatus
+		unknown   = metrics.UnknownStatus
+		skipped   = metrics.SkippedStatus
+	)
+	testCases := []struct {
+		name              string
+		config            *config.CheckerConfig
+		statuses          []string
+		expectedEffective []string
+	}{
+		{
+			name:              "default thresholds follow every result",
+			statuses:          []string{healthy, unhealthy, healthy},
+			expectedEffective: []string{healthy, unhealthy, healthy},
+		},
+		{
+			name:              "failure threshold delays unhealthy",
+			config:            &config.CheckerConfig{Name: "chk", FailureThreshold: 3},
+			statuses:          []string{healthy, unhealthy, unhealthy, healthy, unhealthy, unknown, unhealthy},
+			expectedEffective: []string{healthy, healthy, healthy, healthy, healthy, healthy, unhealthy},
+		},
+		{
+			name:              "success threshold delays recovery",
+			config:            &config.CheckerConfig{Name: "chk", SuccessThreshold: 2},
+			statuses:          []string{unhealthy, healthy, unhealthy, healthy, healthy},
+			expectedEffective: []string{unhealthy, unhealthy, unhealthy, unhealthy, healthy},
+		},
+		{
+			name:              "changes within a class apply immediately",
+			config:            &config.CheckerConfig{Name: "chk", FailureThreshold: 2, SuccessThreshold: 2},
+			statuses:          []string{unhealthy, unknown, skipped, skipped, healthy},
+			expectedEffective: []string{"", unknown, unknown, skipped, healthy},
+		},
+		{
+			name:              "first failures below the failure threshold are pending",
+			config:            &config.CheckerConfig{Name: "chk", FailureThreshold: 3},
+			statuses:          []string{unhealthy, unknown, unhealthy, healthy},
+			expectedEffective: []string{"", "", unhealthy, healthy},
+		},
+		{
+			name:              "pending status becomes passing right away",
+			config:            &config.CheckerConfig{Name: "chk", FailureThreshold: 3, SuccessThreshold: 2},
+			statuses:          []string{unhealthy, healthy, unhealthy, unhealthy, unhealthy},
+			expectedEffective: []string{"", healthy, healthy, healthy, unhealthy},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			store := &effectiveStatusStore{
+				thresholds: make(map[checkerKey]statusThresholds),
+				states:     make(map[trackerKey]*effectiveState),
+			}
+			if tc.config != nil {
+				store.configure(checkerKey{name: "chk"}, tc.config)
+			}
+
+			now := time.Now()
+			var effective []string
+			for _, status := range tc.statuses {
+				e, _, flapping, flapDetection := store.observe(trackerKey{checkerName: "chk"}, status, now)
+				g.Expect(flapping).To(BeFalse())
+				g.Expect(flapDetection).To(BeFalse())
+				effective = append(effective, e)
+			}
+			g.Expect(effective).To(Equal(tc.expectedEffective))
+		})
+	}
+}
+
+func TestEffectiveStatusStore_Flapping(t *testing.T) {
+	g := NewWithT(t)
+	store := &effectiveStatusStore{
+		thresholds: make(map[checkerKey]statusThresholds),
+		states:     make(map[trackerKey]*effectiveState),
+	}
+	store.configure(checkerKey{name: "chk"}, &config.CheckerConfig{
+		Name:             "chk",
+		FailureThreshold: 3,
+		FlapDetection:    &config.FlapDetectionConfig{MaxTransitions: 2, Window: time.Minute},
+	})
+	key := trackerKey{checkerName: "chk", target: "coredns-1"}
+
+	start := time.Now()
+	statuses := []string{metrics.HealthyStatus, metrics.UnhealthyStatus, metrics.HealthyStatus, metrics.UnhealthyStatus}
+	var flapping bool
+	for i, status := range statuses {
+		var effective string
+		effective, _, flapping, _ = store.observe(key, status, start.Add(time.Duration(i)*10*time.Second))
+		g.Expect(effective).To(Equal(metrics.HealthyStatus))
+	}
+	g.Expect(flapping).To(BeTrue(), "3 changes within a minute exceed 2 transitions")
+
+	// The changes fall out of the window while the status stays the same.
+	_, _, flapping, _ = store.observe(key, metrics.UnhealthyStatus, start.Add(2*time.Minute))
+	g.Expect(flapping).To(BeFalse())
+
+	store.forget(checkerKey{name: "chk"})
+	g.Expect(store.states).To(BeEmpty())
+	g.Expect(store.thresholds).To(BeEmpty())
+}
@@ -1,9 +1,27 @@
 package checker
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrSkipChecker signals that a checker should be skipped without causing application failure.
 	// This can be used when a checker determines it's not applicable in the current environment.
 	ErrSkipChecker = errors.New("skip checker")
 )
+
+// PanicError is the error recorded for a checker run that panicked. Value is the value the checker panicked with.
+type PanicError struct {
+	Value any
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("checker panicked: %v", e.Value)
+}
+
+// IsPanic returns whether err is or wraps a PanicError.
+func IsPanic(err error) bool {
+	var panicErr *PanicError
+	return errors.As(err, &panicErr)
+}
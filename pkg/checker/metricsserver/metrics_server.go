@@ -9,7 +9,6 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 	metricsclientset "k8s.io/metrics/pkg/client/clientset/versioned"
 
@@ -17,6 +16,9 @@ import (
 	"github.com/Azure/cluster-health-monitor/pkg/config"
 )
 
+// stepListNodeMetrics is the step of the MetricsServerChecker's run that is traced.
+const stepListNodeMetrics = "ListNodeMetrics"
+
 // MetricsServerChecker implements the Checker interface for metrics server checks.
 type MetricsServerChecker struct {
 	name          string
@@ -30,15 +32,13 @@ func Register() {
 }
 
 // BuildMetricsServerChecker creates a new MetricsServerChecker instance.
-func BuildMetricsServerChecker(config *config.CheckerConfig, kubeClient kubernetes.Interface) (checker.Checker, error) {
-	// Get in-cluster config to access the API server
-	k8sConfig, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+func BuildMetricsServerChecker(config *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
+	if cluster.RESTConfig == nil {
+		return nil, errors.New("cluster REST config cannot be nil")
 	}
 
 	// Create metrics client using the official Kubernetes metrics client
-	metricsClient, err := metricsclientset.NewForConfig(k8sConfig)
+	metricsClient, err := metricsclientset.NewForConfig(cluster.RESTConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create metrics client: %w", err)
 	}
@@ -46,7 +46,7 @@ func BuildMetricsServerChecker(config *config.CheckerConfig, kubeClient kubernet
 	chk := &MetricsServerChecker{
 		name:          config.Name,
 		timeout:       config.Timeout,
-		kubeClient:    kubeClient,
+		kubeClient:    cluster.KubeClient,
 		metricsClient: metricsClient,
 	}
 	klog.InfoS("Built MetricsServerChecker",
@@ -64,9 +64,14 @@ func (c *MetricsServerChecker) Type() config.CheckerType {
 	return config.CheckTypeMetricsServer
 }
 
+// ReadOnly returns true as the metrics server checker only reads node and pod metrics.
+func (c *MetricsServerChecker) ReadOnly() bool {
+	return true
+}
+
 func (c *MetricsServerChecker) Run(ctx context.Context) {
 	result, err := c.check(ctx)
-	checker.RecordResult(c, result, err)
+	checker.RecordResult(ctx, c, result, err)
 }
 
 // check executes the metrics server check.
@@ -85,7 +90,9 @@ func (c *MetricsServerChecker) check(ctx context.Context) (*checker.Result, erro
 
 func (c *MetricsServerChecker) checkMetricsServerAPI(ctx context.Context) error {
 	// Make a simple call to the metrics server API to check its availability
+	ctx, span := checker.StartSpan(ctx, stepListNodeMetrics)
 	_, err := c.metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+	checker.EndSpan(span, err)
 	if err != nil {
 		return fmt.Errorf("metrics server API call failed: %w", err)
 	}
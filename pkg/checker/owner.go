@@ -0,0 +1,43 @@
+package checker
+
+import (
+	"sync/atomic"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Owners are the objects set as owners of the objects created by checkers, so that the Kubernetes garbage collector deletes the objects
+// created by checkers once the monitor is uninstalled, even if the monitor could not delete them itself.
+type Owners struct {
+	// Namespace is the namespace of the Namespaced owner.
+	Namespace string
+	// Namespaced owns the namespaced objects created in Namespace, e.g. it is the Deployment of the monitor.
+	Namespaced *metav1.OwnerReference
+	// Cluster is a cluster-scoped anchor object. It owns the cluster-scoped objects, and the namespaced objects created in other
+	// namespaces than Namespace, since the owner of a namespaced object must be in the same namespace or cluster-scoped.
+	Cluster *metav1.OwnerReference
+}
+
+var owners atomic.Pointer[Owners]
+
+// SetOwners sets the owners of the objects created by checkers. Nil owners disable owner references.
+func SetOwners(o *Owners) {
+	owners.Store(o)
+}
+
+// OwnerReferences returns the owner references to set on an object created by a checker in namespace, or on a cluster-scoped object if
+// namespace is empty. It returns nil if no owner is set for the object.
+func OwnerReferences(namespace string) []metav1.OwnerReference {
+	o := owners.Load()
+	if o == nil {
+		return nil
+	}
+	owner := o.Cluster
+	if namespace != "" && namespace == o.Namespace && o.Namespaced != nil {
+		owner = o.Namespaced
+	}
+	if owner == nil {
+		return nil
+	}
+	return []metav1.OwnerReference{*owner}
+}
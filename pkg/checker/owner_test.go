@@ -0,0 +1,26 @@
+package checker
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnerReferences(t *testing.T) {
+	g := NewWithT(t)
+	t.Cleanup(func() { SetOwners(nil) })
+	deployment := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "cluster-health-monitor", UID: "1"}
+	anchor := &metav1.OwnerReference{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "anchor", UID: "2"}
+
+	g.Expect(OwnerReferences("kube-system")).To(BeNil(), "no owner references without owners")
+
+	SetOwners(&Owners{Namespace: "kube-system", Namespaced: deployment, Cluster: anchor})
+	g.Expect(OwnerReferences("kube-system")).To(Equal([]metav1.OwnerReference{*deployment}))
+	g.Expect(OwnerReferences("default")).To(Equal([]metav1.OwnerReference{*anchor}), "objects in other namespaces are owned by the anchor")
+	g.Expect(OwnerReferences("")).To(Equal([]metav1.OwnerReference{*anchor}))
+
+	SetOwners(&Owners{Namespace: "kube-system", Namespaced: deployment})
+	g.Expect(OwnerReferences("kube-system")).To(Equal([]metav1.OwnerReference{*deployment}))
+	g.Expect(OwnerReferences("")).To(BeNil())
+}
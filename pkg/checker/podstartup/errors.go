@@ -7,4 +7,7 @@ const (
 	ErrCodePodStartupDurationExceeded = "PodStartupDurationExceeded"
 	ErrCodeRequestFailed              = "RequestFailed"
 	ErrCodeRequestTimeout             = "RequestTimeout"
+
+	// This is the reason code of the PodStartupChecker's skipped result.
+	ReasonCodeNodePoolCRDNotFound = "NodePoolCRDNotFound"
 )
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -99,6 +100,7 @@ func (c *PodStartupChecker) karpenterNodePool(nodePoolName, timestampStr string)
 			Labels: map[string]string{
 				c.config.SyntheticPodLabelKey: timestampStr,
 			},
+			OwnerReferences: checker.OwnerReferences(""),
 		},
 		Spec: karpenter.NodePoolSpec{
 			Template: karpenter.NodeClaimTemplate{
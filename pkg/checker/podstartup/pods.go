@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
 	"github.com/Azure/cluster-health-monitor/pkg/config"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -146,8 +147,9 @@ func (c *PodStartupChecker) generateSyntheticPod(timestampStr string) *corev1.Po
 
 	return &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   podName,
-			Labels: c.syntheticPodLabels(),
+			Name:            podName,
+			Labels:          c.syntheticPodLabels(),
+			OwnerReferences: checker.OwnerReferences(c.config.SyntheticPodNamespace),
 		},
 		Spec: podSpec,
 	}
@@ -165,7 +167,8 @@ func (c *PodStartupChecker) getSyntheticPodIP(ctx context.Context, podName strin
 	return pod.Status.PodIP, nil
 }
 
-func (c *PodStartupChecker) syntheticPodGarbageCollection(ctx context.Context) error {
+// syntheticPodGarbageCollection deletes the synthetic pods created by the checker that are older than minAge.
+func (c *PodStartupChecker) syntheticPodGarbageCollection(ctx context.Context, minAge time.Duration) error {
 	podList, err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(labels.Set(c.syntheticPodLabels())).String(),
 	})
@@ -174,7 +177,7 @@ func (c *PodStartupChecker) syntheticPodGarbageCollection(ctx context.Context) e
 	}
 	var errs []error
 	for _, pod := range podList.Items {
-		if time.Since(pod.CreationTimestamp.Time) > c.timeout {
+		if time.Since(pod.CreationTimestamp.Time) > minAge {
 			err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
 			if err != nil && !apierrors.IsNotFound(err) {
 				errs = append(errs, fmt.Errorf("failed to delete old synthetic pod %s: %w", pod.Name, err))
@@ -324,7 +324,7 @@ func TestPodStartupChecker_syntheticPodgarbageCollection(t *testing.T) {
 			}
 
 			// Run garbage collect
-			err := checker.syntheticPodGarbageCollection(context.Background())
+			err := checker.syntheticPodGarbageCollection(context.Background(), checker.timeout)
 
 			// Get pods for validation
 			pods, listErr := tt.client.CoreV1().Pods(syntheticPodNamespace).List(context.Background(), metav1.ListOptions{})
@@ -17,7 +17,6 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
 
 	"github.com/Azure/cluster-health-monitor/pkg/checker"
@@ -35,6 +34,25 @@ const (
 
 	// syntheticPodPort is the hardcoded TCP port that synthetic pods listen on for connectivity testing.
 	syntheticPodPort = 80
+
+	// stepPodStartup is the step under which the computed pod startup duration of each run is recorded.
+	stepPodStartup = "PodStartup"
+
+	// These are the steps of the PodStartupChecker's run that are traced.
+	stepGarbageCollect          = "GarbageCollect"
+	stepCheckCSIResourceLimit   = "CheckCSIResourceLimit"
+	stepCreateCSIResources      = "CreateCSIResources"
+	stepDeleteCSIResources      = "DeleteCSIResources"
+	stepListPods                = "ListPods"
+	stepCheckNodePoolCRD        = "CheckNodePoolCRD"
+	stepCreateNodePool          = "CreateNodePool"
+	stepDeleteNodePool          = "DeleteNodePool"
+	stepCreatePod               = "CreatePod"
+	stepDeletePod               = "DeletePod"
+	stepWaitForContainerRunning = "WaitForContainerRunning"
+	stepGetImagePullDuration    = "GetImagePullDuration"
+	stepGetPodIP                = "GetPodIP"
+	stepTCPDial                 = "TCPDial"
 )
 
 type PodStartupChecker struct {
@@ -63,12 +81,12 @@ func Register() {
 }
 
 // BuildPodStartupChecker creates a new PodStartupChecker instance.
-func BuildPodStartupChecker(config *config.CheckerConfig, kubeClient kubernetes.Interface) (checker.Checker, error) {
+func BuildPodStartupChecker(config *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, error) {
 	chk := &PodStartupChecker{
 		name:         config.Name,
 		config:       config.PodStartupConfig,
 		timeout:      config.Timeout,
-		k8sClientset: kubeClient,
+		k8sClientset: cluster.KubeClient,
 		dialer: &net.Dialer{
 			Timeout: config.PodStartupConfig.TCPTimeout,
 		},
@@ -79,13 +97,12 @@ func BuildPodStartupChecker(config *config.CheckerConfig, kubeClient kubernetes.
 		"timeout", chk.timeout.String(),
 	)
 
-	restConfig, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
+	if cluster.RESTConfig == nil {
+		return nil, errors.New("cluster REST config cannot be nil")
 	}
 
 	// create a dynamic client to interact with Karpenter's custom resources
-	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	dynamicClient, err := dynamic.NewForConfig(cluster.RESTConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
@@ -104,7 +121,7 @@ func (c *PodStartupChecker) Type() config.CheckerType {
 
 func (c *PodStartupChecker) Run(ctx context.Context) {
 	result, err := c.check(ctx)
-	checker.RecordResult(c, result, err)
+	checker.RecordResult(ctx, c, result, err)
 }
 
 // Run executes the pod startup checker logic. It creates synthetic pods to measure the startup time. The startup time is defined as the
@@ -113,30 +130,40 @@ func (c *PodStartupChecker) Run(ctx context.Context) {
 // garbage collect any leftover synthetic pods from previous runs that may not have been previously deleted due to errors or other issues.
 func (c *PodStartupChecker) check(ctx context.Context) (*checker.Result, error) {
 	// Garbage collect any leftover synthetic pods previously created by this checker.
-	if err := c.garbageCollect(ctx); err != nil {
+	if err := traceStep(ctx, stepGarbageCollect, c.garbageCollect); err != nil {
 		// Logging instead of returning an error here to avoid failing the checker run.
 		klog.ErrorS(err, "Failed to garbage collect old synthetic pods")
 	}
 
 	timeStampStr := fmt.Sprintf("%d", time.Now().UnixNano())
 
-	if err := c.checkCSIResourceLimit(ctx); err != nil {
+	if err := traceStep(ctx, stepCheckCSIResourceLimit, c.checkCSIResourceLimit); err != nil {
 		return nil, fmt.Errorf("CSI resource limit check failed: %w", err)
 	}
 
-	if err := c.createCSIResources(ctx, timeStampStr); err != nil {
+	if err := traceStep(ctx, stepCreateCSIResources, func(ctx context.Context) error {
+		return c.createCSIResources(ctx, timeStampStr)
+	}); err != nil {
 		return nil, fmt.Errorf("failed to create CSI test resources: %w", err)
 	}
+	// The resources created during the run are deleted with a separate context, so that they are also deleted if the run timed out or the
+	// monitor is shutting down.
 	defer func() {
-		if err := c.deleteCSIResources(ctx, timeStampStr); err != nil {
+		cleanupCtx, cancel := checker.CleanupContext(ctx)
+		defer cancel()
+		if err := traceStep(cleanupCtx, stepDeleteCSIResources, func(ctx context.Context) error {
+			return c.deleteCSIResources(ctx, timeStampStr)
+		}); err != nil {
 			klog.ErrorS(err, "Failed to delete CSI test resources")
 		}
 	}()
 
 	// List pods to check the current number of synthetic pods. Do not run the checker if the maximum number of synthetic pods has been reached.
-	pods, err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).List(ctx, metav1.ListOptions{
+	listCtx, listSpan := checker.StartSpan(ctx, stepListPods)
+	pods, err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).List(listCtx, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(labels.Set(c.syntheticPodLabels())).String(),
 	})
+	checker.EndSpan(listSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list pods: %w", err)
 	}
@@ -145,24 +172,30 @@ func (c *PodStartupChecker) check(ctx context.Context) (*checker.Result, error)
 			len(pods.Items), c.config.MaxSyntheticPods)
 	}
 
-	nodePoolName := fmt.Sprintf("%s-nodepool-%s", strings.ToLower(c.name), timeStampStr)
+	nodePoolName := c.nodePoolNamePrefix() + timeStampStr
 
 	if c.config.EnableNodeProvisioningTest {
-		karpenterNodePoolCRDPresent, err := c.isKarpenterNodePoolCRDPresent(ctx)
+		crdCtx, crdSpan := checker.StartSpan(ctx, stepCheckNodePoolCRD)
+		karpenterNodePoolCRDPresent, err := c.isKarpenterNodePoolCRDPresent(crdCtx)
+		checker.EndSpan(crdSpan, err)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check Karpenter NodePool CRD presence: %w", err)
 		}
 		if !karpenterNodePoolCRDPresent {
-			return checker.Skipped("Karpenter NodePool CRD was not found, pod startup test was skipped"), nil
+			return checker.Skipped(ReasonCodeNodePoolCRDNotFound, "Karpenter NodePool CRD was not found, pod startup test was skipped"), nil
 		}
 		// create a NodePool first, then create synthetic pods on a new node from the node pool.
-		if err := c.createKarpenterNodePool(ctx, c.karpenterNodePool(nodePoolName, timeStampStr)); err != nil {
+		if err := traceStep(ctx, stepCreateNodePool, func(ctx context.Context) error {
+			return c.createKarpenterNodePool(ctx, c.karpenterNodePool(nodePoolName, timeStampStr))
+		}); err != nil {
 			return nil, fmt.Errorf("failed to create Karpenter NodePool: %w", err)
 		}
 	}
 
 	// Create a synthetic pod to measure the startup time.
-	synthPod, err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).Create(ctx, c.generateSyntheticPod(timeStampStr), metav1.CreateOptions{})
+	createCtx, createSpan := checker.StartSpan(ctx, stepCreatePod)
+	synthPod, err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).Create(createCtx, c.generateSyntheticPod(timeStampStr), metav1.CreateOptions{})
+	checker.EndSpan(createSpan, err)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodePodCreationTimeout, "timed out creating synthetic pod"), nil
@@ -170,44 +203,63 @@ func (c *PodStartupChecker) check(ctx context.Context) (*checker.Result, error)
 		return checker.Unhealthy(ErrCodePodCreationError, fmt.Sprintf("error creating synthetic pod: %s", err)), nil
 	}
 	defer func() {
-		err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).Delete(ctx, synthPod.Name, metav1.DeleteOptions{})
-		if err != nil && !apierrors.IsNotFound(err) {
+		cleanupCtx, cancel := checker.CleanupContext(ctx)
+		defer cancel()
+		err := traceStep(cleanupCtx, stepDeletePod, func(ctx context.Context) error {
+			err := c.k8sClientset.CoreV1().Pods(c.config.SyntheticPodNamespace).Delete(ctx, synthPod.Name, metav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
 			// Logging instead of returning an error here to avoid failing the checker run.
 			klog.ErrorS(err, "Failed to delete synthetic pod", "name", synthPod.Name)
 		}
 
 		if c.config.EnableNodeProvisioningTest {
-			if err := c.deleteKarpenterNodePool(ctx, nodePoolName); err != nil {
+			if err := traceStep(cleanupCtx, stepDeleteNodePool, func(ctx context.Context) error {
+				return c.deleteKarpenterNodePool(ctx, nodePoolName)
+			}); err != nil {
 				klog.ErrorS(err, "Failed to delete Karpenter NodePool", "name", nodePoolName)
 			}
 		}
 	}()
 
-	podCreationToContainerRunningDuration, err := c.pollPodCreationToContainerRunningDuration(ctx, synthPod.Name)
+	pollCtx, pollSpan := checker.StartSpan(ctx, stepWaitForContainerRunning)
+	podCreationToContainerRunningDuration, err := c.pollPodCreationToContainerRunningDuration(pollCtx, synthPod.Name)
+	checker.EndSpan(pollSpan, err)
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodePodStartupDurationExceeded, "pod has no running container"), nil
 		}
 		return nil, fmt.Errorf("pod has no running container: %w", err)
 	}
-	imagePullDuration, err := c.getImagePullDuration(ctx, synthPod.Name)
+	eventsCtx, eventsSpan := checker.StartSpan(ctx, stepGetImagePullDuration)
+	imagePullDuration, err := c.getImagePullDuration(eventsCtx, synthPod.Name)
+	checker.EndSpan(eventsSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get image pull duration: %w", err)
 	}
 
 	// Calculate the pod startup duration. Round to the seconds place because that is the unit of the least precise measurement.
 	podStartupDuration := (podCreationToContainerRunningDuration - imagePullDuration).Round(time.Second)
+	checker.RecordStepDuration(ctx, c, stepPodStartup, podStartupDuration)
 	if podStartupDuration >= c.config.SyntheticPodStartupTimeout {
 		return checker.Unhealthy(ErrCodePodStartupDurationExceeded, "pod exceeded the maximum healthy startup duration"), nil
 	}
 
 	// perform pod communication check - get pod IP and create TCP connection
-	podIP, err := c.getSyntheticPodIP(ctx, synthPod.Name)
+	ipCtx, ipSpan := checker.StartSpan(ctx, stepGetPodIP)
+	podIP, err := c.getSyntheticPodIP(ipCtx, synthPod.Name)
+	checker.EndSpan(ipSpan, err)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get synthetic pod IP: %w", err)
 	}
 
-	err = c.createTCPConnection(ctx, podIP)
+	err = traceStep(ctx, stepTCPDial, func(ctx context.Context) error {
+		return c.createTCPConnection(ctx, podIP)
+	})
 	if err != nil {
 		if errors.Is(err, context.DeadlineExceeded) {
 			return checker.Unhealthy(ErrCodeRequestTimeout, "TCP request to synthetic pod timed out"), nil
@@ -218,10 +270,54 @@ func (c *PodStartupChecker) check(ctx context.Context) (*checker.Result, error)
 	return checker.Healthy(), nil
 }
 
+// traceStep runs a step of the check in a span.
+func traceStep(ctx context.Context, step string, fn func(ctx context.Context) error) error {
+	ctx, span := checker.StartSpan(ctx, step)
+	err := fn(ctx)
+	checker.EndSpan(span, err)
+	return err
+}
+
+// GarbageCollect implements checker.GarbageCollector.
+func (c *PodStartupChecker) GarbageCollect(ctx context.Context) error {
+	return c.garbageCollect(ctx)
+}
+
+// Sweep implements checker.Sweeper.
+func (c *PodStartupChecker) Sweep(ctx context.Context) error {
+	return c.deleteResourcesOlderThan(ctx, 0)
+}
+
+// ResourceLabelKey implements checker.ResourceOwner.
+func (c *PodStartupChecker) ResourceLabelKey() string {
+	return c.config.SyntheticPodLabelKey
+}
+
+// OwnsResource implements checker.ResourceOwner. The checker owns the pods and persistent volume claims in its synthetic pod namespace and
+// the storage classes that are labeled with its name, and the Karpenter NodePools whose name starts with its NodePool name prefix, since
+// NodePools are labeled with the timestamp of the run instead.
+func (c *PodStartupChecker) OwnsResource(obj metav1.Object) bool {
+	if obj.GetNamespace() != "" && obj.GetNamespace() != c.config.SyntheticPodNamespace {
+		return false
+	}
+	return obj.GetLabels()[c.config.SyntheticPodLabelKey] == c.name || strings.HasPrefix(obj.GetName(), c.nodePoolNamePrefix())
+}
+
+// nodePoolNamePrefix returns the prefix of the names of the Karpenter NodePools created by the checker.
+func (c *PodStartupChecker) nodePoolNamePrefix() string {
+	return strings.ToLower(c.name) + "-nodepool-"
+}
+
 // garbageCollect deletes all pods created by the checker that are older than the checker's timeout.
 func (c *PodStartupChecker) garbageCollect(ctx context.Context) error {
+	return c.deleteResourcesOlderThan(ctx, c.timeout)
+}
+
+// deleteResourcesOlderThan deletes the synthetic pods, persistent volume claims and storage classes created by the checker that are older
+// than minAge, and all Karpenter NodePools created by the checker.
+func (c *PodStartupChecker) deleteResourcesOlderThan(ctx context.Context, minAge time.Duration) error {
 	var errs []error
-	if err := c.syntheticPodGarbageCollection(ctx); err != nil {
+	if err := c.syntheticPodGarbageCollection(ctx, minAge); err != nil {
 		errs = append(errs, fmt.Errorf("failed to garbage collect outdated synthetic pods: %w", err))
 	}
 
@@ -232,11 +328,11 @@ func (c *PodStartupChecker) garbageCollect(ctx context.Context) error {
 		}
 	}
 
-	if err := c.persistentVolumeClaimGarbageCollection(ctx); err != nil {
+	if err := c.persistentVolumeClaimGarbageCollection(ctx, minAge); err != nil {
 		errs = append(errs, fmt.Errorf("failed to garbage collect outdated persistent volume claims: %w", err))
 	}
 
-	if err := c.storageClassGarbageCollection(ctx); err != nil {
+	if err := c.storageClassGarbageCollection(ctx, minAge); err != nil {
 		errs = append(errs, fmt.Errorf("failed to garbage collect outdated storage classes: %w", err))
 	}
 
@@ -345,6 +345,7 @@ func TestPodStartupChecker_check(t *testing.T) {
 				g.Expect(err).ToNot(HaveOccurred())
 				g.Expect(result).ToNot(BeNil())
 				g.Expect(result.Status).To(Equal(checker.StatusSkipped))
+				g.Expect(result.Detail.Code).To(Equal(ReasonCodeNodePoolCRDNotFound))
 
 				g.Expect(fakeDynamicClient.Actions()).To(HaveLen(2))
 				g.Expect(fakeDynamicClient.Actions()[0].GetResource()).To(Equal(NodePoolGVR))
@@ -622,6 +623,29 @@ func TestPodStartupChecker_garbageCollect(t *testing.T) {
 	}
 }
 
+func TestPodStartupChecker_OwnsResource(t *testing.T) {
+	g := NewWithT(t)
+	labelKey := "cluster-health-monitor/checker-name"
+	checker := &PodStartupChecker{
+		name:   "PodStartup",
+		config: &config.PodStartupConfig{SyntheticPodNamespace: "checker-ns", SyntheticPodLabelKey: labelKey},
+	}
+
+	g.Expect(checker.ResourceLabelKey()).To(Equal(labelKey))
+	g.Expect(checker.OwnsResource(&metav1.ObjectMeta{Name: "podstartup-synthetic-1", Namespace: "checker-ns",
+		Labels: map[string]string{labelKey: "PodStartup"}})).To(BeTrue())
+	g.Expect(checker.OwnsResource(&metav1.ObjectMeta{Name: "clusterhealthmonitor-azurefile-sc-1",
+		Labels: map[string]string{labelKey: "PodStartup"}})).To(BeTrue())
+	g.Expect(checker.OwnsResource(&metav1.ObjectMeta{Name: "podstartup-nodepool-1",
+		Labels: map[string]string{labelKey: "1"}})).To(BeTrue(), "NodePools are owned by name")
+	g.Expect(checker.OwnsResource(&metav1.ObjectMeta{Name: "renamed-nodepool-1",
+		Labels: map[string]string{labelKey: "1"}})).To(BeFalse())
+	g.Expect(checker.OwnsResource(&metav1.ObjectMeta{Name: "renamed-synthetic-1", Namespace: "checker-ns",
+		Labels: map[string]string{labelKey: "Renamed"}})).To(BeFalse())
+	g.Expect(checker.OwnsResource(&metav1.ObjectMeta{Name: "podstartup-synthetic-1", Namespace: "default",
+		Labels: map[string]string{labelKey: "PodStartup"}})).To(BeFalse(), "pods in other namespaces are not owned")
+}
+
 func TestPodStartupChecker_pollPodCreationToContainerRunningDuration(t *testing.T) {
 	podName := "pod1"
 	syntheticPodNamespace := "test"
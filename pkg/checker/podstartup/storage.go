@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
 	"github.com/Azure/cluster-health-monitor/pkg/config"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -35,9 +36,10 @@ func (c *PodStartupChecker) azureDiskPVC(timestampStr string) *corev1.Persistent
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", azureDiskPVCNamePrefix, timestampStr),
-			Namespace: c.config.SyntheticPodNamespace,
-			Labels:    c.syntheticPodLabels(),
+			Name:            fmt.Sprintf("%s-%s", azureDiskPVCNamePrefix, timestampStr),
+			Namespace:       c.config.SyntheticPodNamespace,
+			Labels:          c.syntheticPodLabels(),
+			OwnerReferences: checker.OwnerReferences(c.config.SyntheticPodNamespace),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{
@@ -64,8 +66,9 @@ func (c *PodStartupChecker) azureFileStorageClass(timestampStr string) *storagev
 			APIVersion: "storage.k8s.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:   fmt.Sprintf("%s-%s", azureFileStorageClassNamePrefix, timestampStr),
-			Labels: c.syntheticPodLabels(),
+			Name:            fmt.Sprintf("%s-%s", azureFileStorageClassNamePrefix, timestampStr),
+			Labels:          c.syntheticPodLabels(),
+			OwnerReferences: checker.OwnerReferences(""),
 		},
 		Provisioner:          "file.csi.azure.com",
 		AllowVolumeExpansion: &allowVolumeExpansion,
@@ -93,9 +96,10 @@ func (c *PodStartupChecker) azureFilePVC(timestampStr string) *corev1.Persistent
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", azureFilePVCNamePrefix, timestampStr),
-			Namespace: c.config.SyntheticPodNamespace,
-			Labels:    c.syntheticPodLabels(),
+			Name:            fmt.Sprintf("%s-%s", azureFilePVCNamePrefix, timestampStr),
+			Namespace:       c.config.SyntheticPodNamespace,
+			Labels:          c.syntheticPodLabels(),
+			OwnerReferences: checker.OwnerReferences(c.config.SyntheticPodNamespace),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{
@@ -118,9 +122,10 @@ func (c *PodStartupChecker) azureBlobPVC(timestampStr string) *corev1.Persistent
 			APIVersion: "v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-%s", azureBlobPVCNamePrefix, timestampStr),
-			Namespace: c.config.SyntheticPodNamespace,
-			Labels:    c.syntheticPodLabels(),
+			Name:            fmt.Sprintf("%s-%s", azureBlobPVCNamePrefix, timestampStr),
+			Namespace:       c.config.SyntheticPodNamespace,
+			Labels:          c.syntheticPodLabels(),
+			OwnerReferences: checker.OwnerReferences(c.config.SyntheticPodNamespace),
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes: []corev1.PersistentVolumeAccessMode{
@@ -194,7 +199,8 @@ func (c *PodStartupChecker) deleteCSIResources(ctx context.Context, timestampStr
 	return nil
 }
 
-func (c *PodStartupChecker) persistentVolumeClaimGarbageCollection(ctx context.Context) error {
+// persistentVolumeClaimGarbageCollection deletes the persistent volume claims created by the checker that are older than minAge.
+func (c *PodStartupChecker) persistentVolumeClaimGarbageCollection(ctx context.Context, minAge time.Duration) error {
 	pvcs, err := c.k8sClientset.CoreV1().PersistentVolumeClaims(c.config.SyntheticPodNamespace).List(ctx, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(labels.Set(c.syntheticPodLabels())).String(),
 	})
@@ -205,7 +211,7 @@ func (c *PodStartupChecker) persistentVolumeClaimGarbageCollection(ctx context.C
 	var errs []error
 
 	for _, pvc := range pvcs.Items {
-		if time.Since(pvc.CreationTimestamp.Time) > c.timeout {
+		if time.Since(pvc.CreationTimestamp.Time) > minAge {
 			err := c.k8sClientset.CoreV1().PersistentVolumeClaims(c.config.SyntheticPodNamespace).Delete(ctx, pvc.Name, metav1.DeleteOptions{})
 			if err != nil && !apierrors.IsNotFound(err) {
 				errs = append(errs, fmt.Errorf("failed to delete outdated persistent volume claim %s: %w", pvc.Name, err))
@@ -216,7 +222,8 @@ func (c *PodStartupChecker) persistentVolumeClaimGarbageCollection(ctx context.C
 	return errors.Join(errs...)
 }
 
-func (c *PodStartupChecker) storageClassGarbageCollection(ctx context.Context) error {
+// storageClassGarbageCollection deletes the storage classes created by the checker that are older than minAge.
+func (c *PodStartupChecker) storageClassGarbageCollection(ctx context.Context, minAge time.Duration) error {
 	scs, err := c.k8sClientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{
 		LabelSelector: labels.SelectorFromSet(labels.Set(c.syntheticPodLabels())).String(),
 	})
@@ -226,7 +233,7 @@ func (c *PodStartupChecker) storageClassGarbageCollection(ctx context.Context) e
 
 	var errs []error
 	for _, sc := range scs.Items {
-		if time.Since(sc.CreationTimestamp.Time) > c.timeout {
+		if time.Since(sc.CreationTimestamp.Time) > minAge {
 			err := c.k8sClientset.StorageV1().StorageClasses().Delete(ctx, sc.Name, metav1.DeleteOptions{})
 			if err != nil && !apierrors.IsNotFound(err) {
 				errs = append(errs, fmt.Errorf("failed to delete outdated storage class %s: %w", sc.Name, err))
@@ -324,7 +324,7 @@ func TestPersistentVolumeClaimGarbageCollection(t *testing.T) {
 			}
 
 			// Run garbage collect
-			err := checker.persistentVolumeClaimGarbageCollection(context.Background())
+			err := checker.persistentVolumeClaimGarbageCollection(context.Background(), checker.timeout)
 
 			// Get PVCs and SCs for validation
 			pvcs, listErr := tt.client.CoreV1().PersistentVolumeClaims(syntheticPodNamespace).List(context.Background(), metav1.ListOptions{})
@@ -450,7 +450,7 @@ func TestStorageClassGarbageCollection(t *testing.T) {
 			}
 
 			// Run garbage collect
-			err := checker.storageClassGarbageCollection(context.Background())
+			err := checker.storageClassGarbageCollection(context.Background(), checker.timeout)
 
 			// Get SCs for validation
 			scs, listErr := tt.client.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
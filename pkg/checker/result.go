@@ -14,22 +14,19 @@ const (
 // Result represents the result of a health check.
 type Result struct {
 	// Status indicates the health status of the checker.
-	Status Status
+	Status Status `json:"status"`
 
 	// Detail provides additional information about the health check result if it is not healthy.
-	Detail Detail
+	Detail Detail `json:"detail"`
 }
 
 // Detail provides additional information about the health check result if it is not healthy.
 type Detail struct {
-	// Code is a string that represents the error code of the unhealthy check result.
-	Code string
+	// Code is a string that represents the error code of the unhealthy check result, or the reason code of the skipped check result.
+	Code string `json:"code,omitempty"`
 
 	// Message is a string that provides a human-readable message about the unhealthy result.
-	Message string
-
-	// Pod is the name of the pod associated with this result, if applicable.
-	Pod string
+	Message string `json:"message,omitempty"`
 }
 
 // Healthy is a helper function to create a healthy Result.
@@ -50,17 +47,13 @@ func Unhealthy(code, message string) *Result {
 	}
 }
 
-// Skipped is a helper function to create a skipped Result with a specific code and message.
-func Skipped(message string) *Result {
+// Skipped is a helper function to create a skipped Result with a specific reason code and message.
+func Skipped(code, message string) *Result {
 	return &Result{
 		Status: StatusSkipped,
 		Detail: Detail{
+			Code:    code,
 			Message: message,
 		},
 	}
 }
-
-func (r *Result) WithPod(pod string) *Result {
-	r.Detail.Pod = pod
-	return r
-}
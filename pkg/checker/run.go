@@ -0,0 +1,119 @@
+package checker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// runStatusKey is the context key under which the status of the current checker run is stored.
+type runStatusKey struct{}
+
+// runStatus identifies a single checker run and collects the status recorded during it.
+type runStatus struct {
+	// id is the unique ID of the run.
+	id string
+	// start is the time at which the run started.
+	start time.Time
+
+	mu     sync.Mutex
+	status string
+	// reportedTargets is whether a target recorded a result during the run.
+	reportedTargets bool
+	// records holds the results recorded during the run, in the order they were recorded.
+	records []ResultRecord
+}
+
+// WithRunStatus returns a copy of ctx that collects the status recorded by RecordResult and RecordTargetResult during a single
+// checker run, so that the run can be labeled with it once it completes. It also assigns the run a unique ID, and starts the clock for
+// the durations of the results recorded during the run.
+func WithRunStatus(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runStatusKey{}, &runStatus{id: string(uuid.NewUUID()), start: time.Now()})
+}
+
+// getRunInfo returns the ID of the current run and the time elapsed since it started, as of now. It returns an empty ID and zero
+// duration if ctx does not belong to a run.
+func getRunInfo(ctx context.Context, now time.Time) (string, time.Duration) {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return "", 0
+	}
+	return rs.id, now.Sub(rs.start)
+}
+
+// setRunStatus sets the status of the current run. A checker may record several results in one run, e.g. one per target, in which
+// case the first non-healthy status is kept so that the run is only as healthy as its least healthy result.
+func setRunStatus(ctx context.Context, status string) {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.status == "" || rs.status == metrics.HealthyStatus {
+		rs.status = status
+	}
+}
+
+// getRunStatus returns the status of the current run, or the unknown status if no result was recorded.
+func getRunStatus(ctx context.Context) string {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return metrics.UnknownStatus
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.status == "" {
+		return metrics.UnknownStatus
+	}
+	return rs.status
+}
+
+// setRunReportedTargets marks the current run as having recorded target results.
+func setRunReportedTargets(ctx context.Context) {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.reportedTargets = true
+}
+
+// getRunReportedTargets returns whether the current run recorded any target result.
+func getRunReportedTargets(ctx context.Context) bool {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return false
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.reportedTargets
+}
+
+// addRunRecord adds a result to the results recorded during the current run.
+func addRunRecord(ctx context.Context, record ResultRecord) {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.records = append(rs.records, record)
+}
+
+// RunResults returns the checker-level and target results recorded during a checker run, in the order they were recorded. ctx must be the
+// context returned by WithRunStatus and passed to the checker's Run.
+func RunResults(ctx context.Context) []ResultRecord {
+	rs, ok := ctx.Value(runStatusKey{}).(*runStatus)
+	if !ok {
+		return nil
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return slices.Clone(rs.records)
+}
@@ -0,0 +1,154 @@
+package checker
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// ResultSink is an output that checker results are written to, e.g. Prometheus metrics or logs.
+type ResultSink interface {
+	// Record writes a result. It is called synchronously by RecordResult and RecordTargetResult from the checker's run, so it must not
+	// block.
+	Record(record ResultRecord)
+}
+
+// TargetCleaner is implemented by result sinks that keep state per target, such as metric series. ForgetTarget removes the state of a
+// target that a checker no longer reports results for.
+type TargetCleaner interface {
+	ForgetTarget(cluster, checkerType, checkerName, target string)
+}
+
+// CheckerCleaner is implemented by result sinks that keep state per checker. ForgetChecker removes the state of a checker, and of all of
+// its targets, once the checker is removed from the configuration.
+type CheckerCleaner interface {
+	ForgetChecker(cluster, checkerName string)
+}
+
+// ClosableResultSink is implemented by result sinks that hold resources, such as background workers. Close is called once the sink is
+// replaced by SetResultSinks.
+type ClosableResultSink interface {
+	ResultSink
+	Close()
+}
+
+// ResultSinkBuilder creates a result sink from the configuration.
+type ResultSinkBuilder func(cfg *config.Config) (ResultSink, error)
+
+var sinkRegistry = map[config.ResultSinkType]ResultSinkBuilder{
+	config.ResultSinkPrometheus: func(*config.Config) (ResultSink, error) { return prometheusSink{}, nil },
+	config.ResultSinkLog:        func(*config.Config) (ResultSink, error) { return logSink{}, nil },
+}
+
+// resultSinks holds the sinks that results are written to. It defaults to the built-in sinks of config.DefaultResultSinks.
+var resultSinks atomic.Pointer[[]ResultSink]
+
+func init() {
+	resultSinks.Store(&[]ResultSink{prometheusSink{}, logSink{}})
+}
+
+func RegisterResultSink(t config.ResultSinkType, builder ResultSinkBuilder) {
+	sinkRegistry[t] = builder
+	klog.InfoS("Registered result sink", "type", t)
+}
+
+// SetResultSinks builds the result sinks of the configuration and makes them the sinks that results are written to, in the configured
+// order. If the configuration does not list any sinks, config.DefaultResultSinks are used. Sink types without a registered builder are
+// skipped if they are defaults, so that optional sinks do not have to be linked in. The previous sinks are kept if any of the sinks fails
+// to build, and closed otherwise.
+func SetResultSinks(cfg *config.Config) error {
+	types, defaults := cfg.ResultSinks, false
+	if len(types) == 0 {
+		types, defaults = config.DefaultResultSinks, true
+	}
+	sinks := make([]ResultSink, 0, len(types))
+	closeSinks := func(sinks []ResultSink) {
+		for _, sink := range sinks {
+			if closable, ok := sink.(ClosableResultSink); ok {
+				closable.Close()
+			}
+		}
+	}
+	for _, t := range types {
+		builder, ok := sinkRegistry[t]
+		if !ok && defaults {
+			continue
+		}
+		if !ok {
+			closeSinks(sinks)
+			return fmt.Errorf("unrecognized result sink type: %q", t)
+		}
+		sink, err := builder(cfg)
+		if err != nil {
+			closeSinks(sinks)
+			return fmt.Errorf("failed to build result sink %q: %w", t, err)
+		}
+		sinks = append(sinks, sink)
+	}
+	closeSinks(*resultSinks.Swap(&sinks))
+	return nil
+}
+
+// writeToSinks writes a result record to all result sinks.
+func writeToSinks(record ResultRecord) {
+	for _, sink := range *resultSinks.Load() {
+		sink.Record(record)
+	}
+}
+
+// prometheusSink records results as the result counters and the latest and effective status gauges. The trace ID of a traced run is
+// attached as exemplar to the result counters.
+type prometheusSink struct{}
+
+func (prometheusSink) Record(record ResultRecord) {
+	checkerType := string(record.CheckerType)
+	if record.Target == "" {
+		incWithTraceID(metrics.CheckerResultCounter.WithLabelValues(record.Cluster, checkerType, record.CheckerName, record.Status,
+			record.ErrorCode), record.TraceID)
+	} else {
+		incWithTraceID(metrics.CheckerTargetResultCounter.WithLabelValues(record.Cluster, checkerType, record.CheckerName, record.Target,
+			record.Status, record.ErrorCode), record.TraceID)
+	}
+	recordLatestStatus(record.Cluster, checkerType, record.CheckerName, record.Target, record.Status, record.Timestamp)
+	recordEffectiveStatus(record.Cluster, checkerType, record.CheckerName, record.Target, record.EffectiveStatus, record.Flapping)
+}
+
+// ForgetTarget deletes all series of the target.
+func (prometheusSink) ForgetTarget(cluster, checkerType, checkerName, target string) {
+	labels := prometheus.Labels{"cluster": cluster, "checker_type": checkerType, "checker_name": checkerName, "target": target}
+	metrics.CheckerTargetResultCounter.DeletePartialMatch(labels)
+	metrics.CheckerTargetStatusGauge.DeletePartialMatch(labels)
+	metrics.CheckerTargetLastSuccessTimestamp.DeletePartialMatch(labels)
+	metrics.CheckerTargetEffectiveStatusGauge.DeletePartialMatch(labels)
+	metrics.CheckerTargetFlappingGauge.DeletePartialMatch(labels)
+}
+
+// logSink logs results with klog. Results are logged at verbosity 3, and runs that failed with an error are logged as errors as well.
+type logSink struct{}
+
+func (logSink) Record(record ResultRecord) {
+	keysAndValues := []any{"name", record.CheckerName, "type", string(record.CheckerType)}
+	if record.Cluster != "" {
+		keysAndValues = append(keysAndValues, "cluster", record.Cluster)
+	}
+	if record.Target != "" {
+		keysAndValues = append(keysAndValues, "target", record.Target)
+	}
+	if record.RunID != "" {
+		keysAndValues = append(keysAndValues, "runID", record.RunID)
+	}
+	if record.Err != nil {
+		klog.V(3).InfoS("Recorded checker result", append(keysAndValues, "status", record.Status)...)
+		klog.ErrorS(record.Err, "Failed checker run", keysAndValues...)
+		return
+	}
+	klog.V(3).InfoS("Recorded checker result", append(keysAndValues,
+		"status", record.Status,
+		"errorCode", record.ErrorCode,
+		"message", record.Message,
+		"duration", record.Duration.String())...)
+}
@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fakeSink struct {
+	mu      sync.Mutex
+	records []ResultRecord
+}
+
+func (s *fakeSink) Record(record ResultRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+}
+
+func TestSetResultSinks(t *testing.T) {
+	g := NewWithT(t)
+	sink := &fakeSink{}
+	RegisterResultSink("Fake", func(*config.Config) (ResultSink, error) { return sink, nil })
+	RegisterResultSink("Broken", func(*config.Config) (ResultSink, error) { return nil, errors.New("broken") })
+	t.Cleanup(func() {
+		g.Expect(SetResultSinks(&config.Config{})).To(Succeed())
+	})
+
+	g.Expect(SetResultSinks(&config.Config{ResultSinks: []config.ResultSinkType{"Fake"}})).To(Succeed())
+	chk := &fakeChecker{name: "sink"}
+	ctx := WithRunStatus(context.Background())
+	RecordResult(ctx, chk, Unhealthy("CODE", "message"), nil)
+	RecordTargetResult(ctx, chk, "coredns-1", nil, errors.New("boom"))
+
+	g.Expect(sink.records).To(HaveLen(2))
+	checkerRecord, targetRecord := sink.records[0], sink.records[1]
+	g.Expect(checkerRecord.CheckerName).To(Equal("sink"))
+	g.Expect(checkerRecord.Status).To(Equal(metrics.UnhealthyStatus))
+	g.Expect(checkerRecord.ErrorCode).To(Equal("CODE"))
+	g.Expect(checkerRecord.Message).To(Equal("message"))
+	g.Expect(checkerRecord.RunID).ToNot(BeEmpty())
+	g.Expect(targetRecord.RunID).To(Equal(checkerRecord.RunID), "results of the same run share the run ID")
+	g.Expect(targetRecord.Duration).To(BeNumerically(">=", checkerRecord.Duration))
+	g.Expect(targetRecord.Target).To(Equal("coredns-1"))
+	g.Expect(targetRecord.Status).To(Equal(metrics.UnknownStatus))
+	g.Expect(targetRecord.Message).To(Equal("boom"))
+	g.Expect(testutil.ToFloat64(metrics.CheckerResultCounter.WithLabelValues("", "fake", "sink", metrics.UnhealthyStatus, "CODE"))).To(BeZero(),
+		"the Prometheus sink is not selected")
+
+	// The previous sinks are kept if a sink is unknown or fails to build.
+	g.Expect(SetResultSinks(&config.Config{ResultSinks: []config.ResultSinkType{config.ResultSinkPrometheus, "Unknown"}})).To(MatchError(ContainSubstring("unrecognized")))
+	g.Expect(SetResultSinks(&config.Config{ResultSinks: []config.ResultSinkType{config.ResultSinkPrometheus, "Broken"}})).To(MatchError(ContainSubstring("broken")))
+	RecordResult(context.Background(), chk, Healthy(), nil)
+	g.Expect(sink.records).To(HaveLen(3))
+	g.Expect(sink.records[2].RunID).To(BeEmpty(), "results recorded outside of a run have no run ID")
+}
@@ -0,0 +1,211 @@
+package checker
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+)
+
+// ResultRecord is a result recorded for a checker run, along with the time at which it was recorded. It is what result sinks receive.
+type ResultRecord struct {
+	// Cluster is the name of the cluster the checker checks. It is empty unless the monitor checks several clusters.
+	Cluster string
+	// CheckerName is the name of the checker that produced the result.
+	CheckerName string
+	// CheckerType is the type of the checker that produced the result.
+	CheckerType config.CheckerType
+	// Target is the target the result belongs to, e.g. the name of a CoreDNS pod, for checkers that record one result per target. It is
+	// empty for checker-level results.
+	Target string
+	// Result is the result of the run. It is nil if the run failed with an error.
+	Result *Result
+	// Err is the error that caused the run to fail, if any.
+	Err error
+	// Status is the status label of the result: healthy, unhealthy, skipped, or unknown if the run failed with an error.
+	Status string
+	// ErrorCode is the error code label of the result. Healthy and unknown results use the status as code, except for panics which
+	// use metrics.PanicCode.
+	ErrorCode string
+	// Message is the detail message of the result, or the error message if the run failed with an error.
+	Message string
+	// RunID is the unique ID of the run the result was recorded in. Results recorded outside of a scheduled run have no run ID.
+	RunID string
+	// Duration is the time elapsed between the start of the run and the time the result was recorded.
+	Duration time.Duration
+	// EffectiveStatus is the effective status of the checker, or of the target, after the result. It only changes after the failure or
+	// success threshold of the checker is reached. It is empty while it is pending, i.e. while the first results are failing but fewer
+	// than the failure threshold.
+	EffectiveStatus string
+	// PreviousEffectiveStatus is the effective status before the result. It is empty for the first result of the checker or target, and
+	// while the effective status is pending.
+	PreviousEffectiveStatus string
+	// Flapping is whether the result status of the checker, or of the target, is flapping. It is nil if flap detection is not configured
+	// for the checker.
+	Flapping *bool
+	// TraceID is the ID of the trace of the run the result was recorded in. It is empty if the run was not sampled for tracing.
+	TraceID string
+	// Timestamp is the time at which the result was recorded.
+	Timestamp time.Time
+}
+
+// trackerKey returns the key of the checker or target the record belongs to.
+func (r ResultRecord) trackerKey() trackerKey {
+	return trackerKey{cluster: r.Cluster, checkerName: r.CheckerName, target: r.Target}
+}
+
+// checkerKey returns the key of the checker the record belongs to.
+func (r ResultRecord) checkerKey() checkerKey {
+	return checkerKey{cluster: r.Cluster, name: r.CheckerName}
+}
+
+// checkerKey identifies a checker of a cluster. Checkers of different clusters may have the same name.
+type checkerKey struct {
+	cluster string
+	name    string
+}
+
+const (
+	// defaultTargetGracePeriod is how long a target that is no longer reported is kept if the checker config does not set it.
+	defaultTargetGracePeriod = 5 * time.Minute
+	// defaultMaxTargets is the maximum number of targets kept for a checker if the checker config does not set it.
+	defaultMaxTargets = 100
+)
+
+// targetLimits are the settings used to limit the targets kept for a checker.
+type targetLimits struct {
+	gracePeriod time.Duration
+	maxTargets  int
+}
+
+// resultStore holds the latest result recorded for each checker, and for each target of checkers that record per-target results.
+type resultStore struct {
+	mu       sync.RWMutex
+	checkers map[checkerKey]ResultRecord
+	targets  map[checkerKey]map[string]ResultRecord
+	limits   map[checkerKey]targetLimits
+}
+
+var latestResults = &resultStore{
+	checkers: make(map[checkerKey]ResultRecord),
+	targets:  make(map[checkerKey]map[string]ResultRecord),
+	limits:   make(map[checkerKey]targetLimits),
+}
+
+// configure sets the target limits of a checker.
+func (s *resultStore) configure(key checkerKey, cfg *config.CheckerConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	limits := targetLimits{gracePeriod: cfg.TargetGracePeriod, maxTargets: cfg.MaxTargets}
+	if limits.gracePeriod == 0 {
+		limits.gracePeriod = defaultTargetGracePeriod
+	}
+	if limits.maxTargets == 0 {
+		limits.maxTargets = defaultMaxTargets
+	}
+	s.limits[key] = limits
+}
+
+// forget removes the target limits and all results of a checker.
+func (s *resultStore) forget(key checkerKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.checkers, key)
+	delete(s.targets, key)
+	delete(s.limits, key)
+}
+
+// limitsLocked returns the target limits of a checker. s.mu must be held.
+func (s *resultStore) limitsLocked(key checkerKey) targetLimits {
+	if limits, ok := s.limits[key]; ok {
+		return limits
+	}
+	return targetLimits{gracePeriod: defaultTargetGracePeriod, maxTargets: defaultMaxTargets}
+}
+
+// set stores the latest checker-level result of a checker.
+func (s *resultStore) set(record ResultRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkers[record.checkerKey()] = record
+}
+
+// setTarget stores the latest result of a target. If the target is new and the checker already has the maximum number of targets, the
+// target that was reported least recently is evicted to make room if it was reported before runStart, i.e. not in the same run. Otherwise
+// the result is not stored. It returns the evicted target, if any, and whether the result was stored.
+func (s *resultStore) setTarget(record ResultRecord, runStart time.Time) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := record.checkerKey()
+	targets, ok := s.targets[key]
+	if !ok {
+		targets = make(map[string]ResultRecord)
+		s.targets[key] = targets
+	}
+
+	var evicted string
+	if _, exists := targets[record.Target]; !exists && len(targets) >= s.limitsLocked(key).maxTargets {
+		var oldest time.Time
+		for target, r := range targets {
+			if evicted == "" || r.Timestamp.Before(oldest) {
+				evicted, oldest = target, r.Timestamp
+			}
+		}
+		if !oldest.Before(runStart) {
+			return "", false
+		}
+		delete(targets, evicted)
+	}
+	targets[record.Target] = record
+	return evicted, true
+}
+
+// staleTargets returns the targets of a checker whose latest result was recorded longer than the grace period before now.
+func (s *resultStore) staleTargets(key checkerKey, now time.Time) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cutoff := now.Add(-s.limitsLocked(key).gracePeriod)
+	var stale []string
+	for target, record := range s.targets[key] {
+		if record.Timestamp.Before(cutoff) {
+			stale = append(stale, target)
+		}
+	}
+	return stale
+}
+
+// forgetTarget removes the latest result of a target of a checker.
+func (s *resultStore) forgetTarget(key trackerKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	chkKey := key.checkerKey()
+	delete(s.targets[chkKey], key.target)
+	if len(s.targets[chkKey]) == 0 {
+		delete(s.targets, chkKey)
+	}
+}
+
+// LatestResult returns the latest checker-level result recorded for the named checker of the named cluster. It returns false if the
+// checker has not recorded any result yet.
+func LatestResult(cluster, checkerName string) (ResultRecord, bool) {
+	latestResults.mu.RLock()
+	defer latestResults.mu.RUnlock()
+	record, ok := latestResults.checkers[checkerKey{cluster: cluster, name: checkerName}]
+	return record, ok
+}
+
+// LatestTargetResults returns the latest result recorded for each target of the named checker of the named cluster, sorted by target.
+func LatestTargetResults(cluster, checkerName string) []ResultRecord {
+	latestResults.mu.RLock()
+	defer latestResults.mu.RUnlock()
+	key := checkerKey{cluster: cluster, name: checkerName}
+	records := make([]ResultRecord, 0, len(latestResults.targets[key]))
+	for _, record := range latestResults.targets[key] {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Target < records[j].Target
+	})
+	return records
+}
@@ -0,0 +1,102 @@
+package checker
+
+import (
+	"context"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the tracer that creates the spans of checker runs.
+const tracerName = "github.com/Azure/cluster-health-monitor/pkg/checker"
+
+// Attribute keys of the spans of checker runs.
+const (
+	attrCluster     = attribute.Key("checker.cluster")
+	attrCheckerName = attribute.Key("checker.name")
+	attrCheckerType = attribute.Key("checker.type")
+	attrRunID       = attribute.Key("checker.run_id")
+	attrTarget      = attribute.Key("checker.target")
+	attrStatus      = attribute.Key("checker.status")
+	attrErrorCode   = attribute.Key("checker.error_code")
+)
+
+// StartRunSpan starts the span of a checker run. It must be called with the context returned by WithRunStatus, the spans started with
+// StartSpan during the run are its children. The caller must end the span once the run completes.
+func StartRunSpan(ctx context.Context, chk Checker) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{attrCheckerName.String(chk.Name()), attrCheckerType.String(string(chk.Type()))}
+	if cluster := ClusterFromContext(ctx); cluster != "" {
+		attrs = append(attrs, attrCluster.String(cluster))
+	}
+	if runID, _ := getRunInfo(ctx, time.Now()); runID != "" {
+		attrs = append(attrs, attrRunID.String(runID))
+	}
+	return otel.Tracer(tracerName).Start(ctx, "Run "+string(chk.Type()), trace.WithAttributes(attrs...))
+}
+
+// StartSpan starts the span of a step within a checker run, such as garbage collection, an API call or a DNS query. The caller must end
+// the span with EndSpan.
+func StartSpan(ctx context.Context, step string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, step, trace.WithAttributes(attrs...))
+}
+
+// EndSpan ends a span started with StartSpan, marking it as failed if err is not nil.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceResult adds a result to the span of the run it was recorded in, and marks the span as failed unless the result is healthy or
+// skipped.
+func traceResult(ctx context.Context, record ResultRecord) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	attrs := []attribute.KeyValue{attrStatus.String(record.Status), attrErrorCode.String(record.ErrorCode)}
+	if record.Target != "" {
+		attrs = append(attrs, attrTarget.String(record.Target))
+	}
+	span.AddEvent("Result", trace.WithAttributes(attrs...))
+	if record.Status == metrics.UnhealthyStatus || record.Status == metrics.UnknownStatus {
+		span.SetStatus(codes.Error, record.Message)
+	}
+}
+
+// sampledTraceID returns the ID of the trace ctx belongs to, or an empty string if the trace is not sampled and thus not exported.
+func sampledTraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsSampled() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
+
+// exemplarLabel is the label of the exemplars that link result and duration metrics to the trace of the run.
+const exemplarLabel = "trace_id"
+
+// incWithTraceID increments a counter, with the trace ID as exemplar unless it is empty.
+func incWithTraceID(counter prometheus.Counter, traceID string) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && traceID != "" {
+		adder.AddWithExemplar(1, prometheus.Labels{exemplarLabel: traceID})
+		return
+	}
+	counter.Inc()
+}
+
+// observeWithTraceID observes a value, with the trace ID as exemplar unless it is empty.
+func observeWithTraceID(observer prometheus.Observer, value float64, traceID string) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		exemplarObserver.ObserveWithExemplar(value, prometheus.Labels{exemplarLabel: traceID})
+		return
+	}
+	observer.Observe(value)
+}
@@ -0,0 +1,56 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRunSpan(t *testing.T) {
+	g := NewWithT(t)
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	sink := &fakeSink{}
+	RegisterResultSink("Trace", func(*config.Config) (ResultSink, error) { return sink, nil })
+	g.Expect(SetResultSinks(&config.Config{ResultSinks: []config.ResultSinkType{config.ResultSinkPrometheus, "Trace"}})).To(Succeed())
+	t.Cleanup(func() {
+		g.Expect(SetResultSinks(&config.Config{})).To(Succeed())
+	})
+
+	chk := &fakeChecker{name: "traced"}
+	ctx, span := StartRunSpan(WithRunStatus(context.Background()), chk)
+	_, stepSpan := StartSpan(ctx, "Step")
+	EndSpan(stepSpan, errors.New("step failed"))
+	RecordResult(ctx, chk, Unhealthy("CODE", "message"), nil)
+	span.End()
+
+	spans := recorder.Ended()
+	g.Expect(spans).To(HaveLen(2))
+	step, run := spans[0], spans[1]
+	g.Expect(step.Name()).To(Equal("Step"))
+	g.Expect(step.Parent().SpanID()).To(Equal(run.SpanContext().SpanID()), "steps are children of the run")
+	g.Expect(step.Status().Code).To(Equal(codes.Error))
+	g.Expect(run.Name()).To(Equal("Run fake"))
+	g.Expect(run.Status().Code).To(Equal(codes.Error), "the run span fails with an unhealthy result")
+	g.Expect(run.Events()).To(ContainElement(HaveField("Name", "Result")))
+
+	traceID := run.SpanContext().TraceID().String()
+	g.Expect(sink.records).To(HaveLen(1))
+	g.Expect(sink.records[0].TraceID).To(Equal(traceID))
+	var m dto.Metric
+	g.Expect(metrics.CheckerResultCounter.WithLabelValues("", "fake", "traced", metrics.UnhealthyStatus, "CODE").(prometheus.Metric).Write(&m)).
+		To(Succeed())
+	g.Expect(m.GetCounter().GetExemplar().GetLabel()).To(ContainElement(HaveField("Value", HaveValue(Equal(traceID)))))
+}
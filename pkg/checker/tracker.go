@@ -0,0 +1,109 @@
+package checker
+
+import (
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// Reasons of the events emitted by the result tracker.
+const (
+	EventReasonUnhealthy = "CheckerUnhealthy"
+	EventReasonFailed    = "CheckerFailed"
+	EventReasonRecovered = "CheckerRecovered"
+)
+
+// ResultTracker is the result sink of config.ResultSinkEvent. It remembers the previous status of each checker, and of each target of
+// per-target checkers, and emits a Kubernetes event against an object when the status changes:
+//   - a Warning event when the status becomes Unhealthy, or Unknown because the run failed with an error.
+//   - a Normal event when the status becomes Healthy again after being Unhealthy or Unknown.
+//
+// The first Healthy result of a checker and Skipped results do not emit events.
+type ResultTracker struct {
+	recorder record.EventRecorder
+	object   *corev1.ObjectReference
+
+	mu       sync.Mutex
+	statuses map[trackerKey]Status
+}
+
+type trackerKey struct {
+	cluster     string
+	checkerName string
+	target      string
+}
+
+// checkerKey returns the key of the checker the key belongs to.
+func (k trackerKey) checkerKey() checkerKey {
+	return checkerKey{cluster: k.cluster, name: k.checkerName}
+}
+
+// statusUnknown is the status tracked for runs that failed with an error.
+const statusUnknown Status = "Unknown"
+
+// NewResultTracker creates a result tracker that emits events with recorder against object.
+func NewResultTracker(recorder record.EventRecorder, object *corev1.ObjectReference) *ResultTracker {
+	return &ResultTracker{
+		recorder: recorder,
+		object:   object,
+		statuses: make(map[trackerKey]Status),
+	}
+}
+
+// Record implements ResultSink. It records the status of a result and emits an event if it differs from the previously observed status.
+func (t *ResultTracker) Record(record ResultRecord) {
+	status := statusUnknown
+	if record.Err == nil {
+		status = record.Result.Status
+	}
+	key := record.trackerKey()
+
+	t.mu.Lock()
+	previous, seen := t.statuses[key]
+	t.statuses[key] = status
+	t.mu.Unlock()
+
+	if seen && previous == status {
+		return
+	}
+
+	subject := fmt.Sprintf("Checker %s (%s)", record.CheckerName, record.CheckerType)
+	if record.Cluster != "" {
+		subject = fmt.Sprintf("%s of cluster %s", subject, record.Cluster)
+	}
+	if record.Target != "" {
+		subject = fmt.Sprintf("%s for target %s", subject, record.Target)
+	}
+	switch status {
+	case StatusUnhealthy:
+		t.recorder.Eventf(t.object, corev1.EventTypeWarning, EventReasonUnhealthy, "%s is unhealthy: [%s] %s",
+			subject, record.Result.Detail.Code, record.Result.Detail.Message)
+	case statusUnknown:
+		t.recorder.Eventf(t.object, corev1.EventTypeWarning, EventReasonFailed, "%s failed to run: %v", subject, record.Err)
+	case StatusHealthy:
+		if seen && (previous == StatusUnhealthy || previous == statusUnknown) {
+			t.recorder.Eventf(t.object, corev1.EventTypeNormal, EventReasonRecovered, "%s recovered from %s status", subject, previous)
+		}
+	}
+}
+
+// ForgetTarget implements TargetCleaner. It removes the status of a target, so that its next result is observed as if it was the first.
+func (t *ResultTracker) ForgetTarget(cluster, checkerType, checkerName, target string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.statuses, trackerKey{cluster: cluster, checkerName: checkerName, target: target})
+}
+
+// ForgetChecker implements CheckerCleaner. It removes the status of a checker and of all of its targets.
+func (t *ResultTracker) ForgetChecker(cluster, checkerName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	chkKey := checkerKey{cluster: cluster, name: checkerName}
+	for key := range t.statuses {
+		if key.checkerKey() == chkKey {
+			delete(t.statuses, key)
+		}
+	}
+}
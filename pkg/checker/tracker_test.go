@@ -0,0 +1,139 @@
+package checker
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestResultTracker_Record(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name           string
+		records        []ResultRecord
+		expectedEvents []string
+	}{
+		{
+			name: "first healthy result emits no event",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "DNS", Result: Healthy()},
+			},
+		},
+		{
+			name: "healthy to unhealthy emits warning with code and message",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "DNS", Result: Healthy()},
+				{CheckerName: "chk", CheckerType: "DNS", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+			},
+			expectedEvents: []string{
+				"Warning CheckerUnhealthy Checker chk (DNS) is unhealthy: [DNS_TIMEOUT] query timed out",
+			},
+		},
+		{
+			name: "repeated unhealthy result emits a single warning",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "DNS", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+				{CheckerName: "chk", CheckerType: "DNS", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+			},
+			expectedEvents: []string{
+				"Warning CheckerUnhealthy Checker chk (DNS) is unhealthy: [DNS_TIMEOUT] query timed out",
+			},
+		},
+		{
+			name: "recovery emits normal event",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "DNS", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+				{CheckerName: "chk", CheckerType: "DNS", Result: Healthy()},
+			},
+			expectedEvents: []string{
+				"Warning CheckerUnhealthy Checker chk (DNS) is unhealthy: [DNS_TIMEOUT] query timed out",
+				"Normal CheckerRecovered Checker chk (DNS) recovered from Unhealthy status",
+			},
+		},
+		{
+			name: "run error emits warning and recovers",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "APIServer", Err: errors.New("boom")},
+				{CheckerName: "chk", CheckerType: "APIServer", Result: Healthy()},
+			},
+			expectedEvents: []string{
+				"Warning CheckerFailed Checker chk (APIServer) failed to run: boom",
+				"Normal CheckerRecovered Checker chk (APIServer) recovered from Unknown status",
+			},
+		},
+		{
+			name: "skipped result emits no event",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "PodStartup", Result: Skipped("NODE_POOL_CRD_NOT_FOUND", "skipped")},
+			},
+		},
+		{
+			name: "targets are tracked separately",
+			records: []ResultRecord{
+				{CheckerName: "chk", CheckerType: "DNS", Target: "coredns-1", Result: Healthy()},
+				{CheckerName: "chk", CheckerType: "DNS", Target: "coredns-2", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+				{CheckerName: "chk", CheckerType: "DNS", Target: "coredns-1", Result: Healthy()},
+			},
+			expectedEvents: []string{
+				"Warning CheckerUnhealthy Checker chk (DNS) for target coredns-2 is unhealthy: [DNS_TIMEOUT] query timed out",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			recorder := record.NewFakeRecorder(len(tc.records))
+			tracker := NewResultTracker(recorder, &corev1.ObjectReference{Kind: "Deployment", Namespace: "kube-system", Name: "monitor"})
+
+			for _, r := range tc.records {
+				tracker.Record(r)
+			}
+			close(recorder.Events)
+
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			g.Expect(events).To(Equal(tc.expectedEvents))
+		})
+	}
+}
+
+func TestResultTracker_Forget(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	tracker := NewResultTracker(recorder, &corev1.ObjectReference{Kind: "Deployment", Namespace: "kube-system", Name: "monitor"})
+	unhealthy := []ResultRecord{
+		{CheckerName: "chk", CheckerType: "DNS", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+		{CheckerName: "chk", CheckerType: "DNS", Target: "coredns-1", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+		{CheckerName: "other", CheckerType: "DNS", Result: Unhealthy("DNS_TIMEOUT", "query timed out")},
+	}
+	for _, r := range unhealthy {
+		tracker.Record(r)
+	}
+	g.Expect(recorder.Events).To(HaveLen(3))
+	for range 3 {
+		<-recorder.Events
+	}
+
+	tracker.ForgetChecker("", "chk")
+	for _, r := range unhealthy {
+		tracker.Record(r)
+	}
+	g.Expect(recorder.Events).To(HaveLen(2), "the checker and its target are observed as if for the first time, other checkers are kept")
+	for range 2 {
+		<-recorder.Events
+	}
+
+	tracker.ForgetTarget("", "DNS", "chk", "coredns-1")
+	for _, r := range unhealthy {
+		tracker.Record(r)
+	}
+	g.Expect(recorder.Events).To(HaveLen(1), "only the forgotten target is observed as if for the first time")
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/json"
+	"text/template"
 	"time"
 )
 
@@ -19,6 +21,87 @@ type Config struct {
 	// Required.
 	// The min number is 1, the max number is 20.
 	Checkers []CheckerConfig `yaml:"checkers"`
+
+	// Optional.
+	// The sinks that checker results are written to. Each sink type may be listed once. Defaults to DefaultResultSinks.
+	ResultSinks []ResultSinkType `yaml:"resultSinks,omitempty"`
+
+	// Optional.
+	// The webhooks notified by the Webhook result sink whenever the effective status of a checker changes.
+	Webhooks []WebhookConfig `yaml:"webhooks,omitempty"`
+}
+
+// ResultSinkType is the type of a sink that checker results are written to.
+type ResultSinkType string
+
+const (
+	// ResultSinkPrometheus records results as Prometheus metrics.
+	ResultSinkPrometheus ResultSinkType = "Prometheus"
+	// ResultSinkLog logs results with klog.
+	ResultSinkLog ResultSinkType = "Log"
+	// ResultSinkWebhook notifies the configured webhooks of effective status changes.
+	ResultSinkWebhook ResultSinkType = "Webhook"
+	// ResultSinkEvent emits Kubernetes events when the status of a checker changes.
+	ResultSinkEvent ResultSinkType = "Event"
+)
+
+// DefaultResultSinks are the result sinks used if none are configured.
+var DefaultResultSinks = []ResultSinkType{ResultSinkPrometheus, ResultSinkLog, ResultSinkWebhook, ResultSinkEvent}
+
+// WebhookConfig represents the configuration of a webhook that is notified whenever the effective status of a checker, or of a target of
+// a per-target checker, changes.
+type WebhookConfig struct {
+	// Required.
+	// The unique name of the webhook, used in logs and metrics.
+	Name string `yaml:"name"`
+
+	// Required.
+	// The http or https URL the notifications are POSTed to.
+	URL string `yaml:"url"`
+
+	// Optional.
+	// The names of the checkers the webhook is notified about. The webhook is notified about all checkers if it is empty.
+	Checkers []string `yaml:"checkers,omitempty"`
+
+	// Optional.
+	// A Go text/template that renders the request body from a notification, e.g. to match the payload expected by Slack, Teams or
+	// Alertmanager. The notification is sent as JSON if it is empty. The template may use the json function to quote values.
+	BodyTemplate string `yaml:"bodyTemplate,omitempty"`
+
+	// Optional.
+	// Headers added to every request. The Content-Type defaults to application/json.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Optional.
+	// The timeout of a single request. The string format see https://pkg.go.dev/time#ParseDuration
+	// It must not be negative, 0 defaults to 10 seconds.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// Optional.
+	// The number of times a failed request is retried. Requests are retried on connection errors and on 429 and 5xx responses.
+	// It must not be negative.
+	MaxRetries int `yaml:"maxRetries,omitempty"`
+
+	// Optional.
+	// The delay before the first retry, doubling with every further retry. The string format see https://pkg.go.dev/time#ParseDuration
+	// It must not be negative, 0 defaults to 1 second.
+	RetryBackoff time.Duration `yaml:"retryBackoff,omitempty"`
+
+	// Optional.
+	// A notification of a checker or target is not sent within the dedupe window if the last notification sent for it has the same
+	// effective status. A status that changes back within the window is still sent.
+	// The string format see https://pkg.go.dev/time#ParseDuration
+	// It must not be negative, 0 disables deduplication.
+	DedupeWindow time.Duration `yaml:"dedupeWindow,omitempty"`
+}
+
+// WebhookTemplateFuncs are the functions available to webhook body templates in addition to the built-in functions of text/template.
+var WebhookTemplateFuncs = template.FuncMap{
+	// json returns the JSON encoding of a value, e.g. to embed a message in a JSON body as a quoted and escaped string.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
 }
 
 // CheckerConfig represents the configuration for a specific health checker.
@@ -44,6 +127,51 @@ type CheckerConfig struct {
 	// It must be greater than 0.
 	Timeout time.Duration `yaml:"timeout"`
 
+	// Optional.
+	// Whether the checker runs as soon as it is started, instead of after the first interval.
+	RunOnStart bool `yaml:"runOnStart,omitempty"`
+
+	// Optional.
+	// The maximum random delay added to every run of the checker, so that checkers with the same interval do not run at the same time.
+	// The string format see https://pkg.go.dev/time#ParseDuration
+	// It must not be negative and must be less than the interval.
+	Jitter time.Duration `yaml:"jitter,omitempty"`
+
+	// Optional.
+	// What to do when a run is due while a previous run of the checker is still in progress. Defaults to OverlapPolicySkip.
+	OverlapPolicy OverlapPolicy `yaml:"overlapPolicy,omitempty"`
+
+	// Optional.
+	// The maximum number of runs in progress at the same time if OverlapPolicy is OverlapPolicyConcurrent. It must not be negative, 0
+	// defaults to 2.
+	MaxConcurrentRuns int `yaml:"maxConcurrentRuns,omitempty"`
+
+	// Optional.
+	// The number of consecutive failing results (unhealthy or unknown) required before the effective status of the checker becomes
+	// failing. It must not be negative, 0 defaults to 1.
+	FailureThreshold int `yaml:"failureThreshold,omitempty"`
+
+	// Optional.
+	// The number of consecutive passing results (healthy or skipped) required before the effective status of the checker becomes
+	// passing again. It must not be negative, 0 defaults to 1.
+	SuccessThreshold int `yaml:"successThreshold,omitempty"`
+
+	// Optional.
+	// The configuration for detecting a flapping checker. Flap detection is disabled if it is not set.
+	FlapDetection *FlapDetectionConfig `yaml:"flapDetection,omitempty"`
+
+	// Optional.
+	// How long a target of a checker that records one result per target, e.g. a CoreDNS pod, is kept after the checker stopped reporting
+	// it. Its latest result and metric series are removed afterwards. The string format see https://pkg.go.dev/time#ParseDuration
+	// It must not be negative, 0 defaults to 5 minutes.
+	TargetGracePeriod time.Duration `yaml:"targetGracePeriod,omitempty"`
+
+	// Optional.
+	// The maximum number of targets kept for a checker that records one result per target. Once reached, a new target replaces the
+	// target that was reported least recently if that was not reported in the same run, otherwise the result of the new target is
+	// dropped. It must not be negative, 0 defaults to 100.
+	MaxTargets int `yaml:"maxTargets,omitempty"`
+
 	// Optional.
 	// The configuration for the DNS checker, this field is required if Type is CheckTypeDNS.
 	DNSConfig *DNSConfig `yaml:"dnsConfig,omitempty"`
@@ -57,6 +185,32 @@ type CheckerConfig struct {
 	APIServerConfig *APIServerConfig `yaml:"apiServerConfig,omitempty"`
 }
 
+// OverlapPolicy defines what the scheduler does when a run of a checker is due while a previous run is still in progress.
+type OverlapPolicy string
+
+const (
+	// OverlapPolicySkip skips the due run.
+	OverlapPolicySkip OverlapPolicy = "Skip"
+	// OverlapPolicyQueue queues the due run to start as soon as the previous run completes. At most one run is queued, further due runs
+	// are skipped.
+	OverlapPolicyQueue OverlapPolicy = "Queue"
+	// OverlapPolicyConcurrent starts the due run alongside the previous runs, up to MaxConcurrentRuns runs in progress. Further due runs
+	// are skipped.
+	OverlapPolicyConcurrent OverlapPolicy = "Concurrent"
+)
+
+// FlapDetectionConfig configures when a checker is considered flapping.
+type FlapDetectionConfig struct {
+	// Required.
+	// The checker is flapping when its result status changes more than MaxTransitions times within Window.
+	// It must be greater than 0.
+	MaxTransitions int `yaml:"maxTransitions"`
+	// Required.
+	// The sliding window in which status changes are counted. The string format see https://pkg.go.dev/time#ParseDuration
+	// It must be greater than 0.
+	Window time.Duration `yaml:"window"`
+}
+
 type DNSConfig struct {
 	// Required.
 	// The domain to check, used to determine the DNS records to query.
@@ -27,3 +27,17 @@ func ParseFromYAML(cfgData []byte) (*Config, error) {
 	}
 	return &cfg, nil
 }
+
+// ParseCheckerConfig parses the configuration of a single checker from YAML, e.g. from the spec of a HealthCheck resource, and validates
+// it. The name of the checker is set to name.
+func ParseCheckerConfig(name string, chkData []byte) (*CheckerConfig, error) {
+	var chk CheckerConfig
+	if err := yaml.Unmarshal(chkData, &chk); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal yaml: %w", err)
+	}
+	chk.Name = name
+	if err := chk.validate(); err != nil {
+		return nil, fmt.Errorf("checker config validation failed: %w", err)
+	}
+	return &chk, nil
+}
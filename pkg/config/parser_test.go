@@ -2,6 +2,7 @@ package config
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 )
@@ -44,3 +45,35 @@ func TestParseFromFile_NotExist(t *testing.T) {
 	_, err := ParseFromFile("/tmp/does-not-exist.yaml")
 	g.Expect(err).To(HaveOccurred())
 }
+
+func TestParseCheckerConfig_Valid(t *testing.T) {
+	g := NewWithT(t)
+	yamlData := []byte(`
+type: DNS
+interval: 10s
+timeout: 5s
+failureThreshold: 3
+dnsConfig:
+  domain: example.com
+  queryTimeout: 2s
+  target: CoreDNS
+`)
+	chk, err := ParseCheckerConfig("dns1", yamlData)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(chk.Name).To(Equal("dns1"))
+	g.Expect(chk.Interval).To(Equal(10 * time.Second))
+	g.Expect(chk.FailureThreshold).To(Equal(3))
+	g.Expect(chk.DNSConfig.Domain).To(Equal("example.com"))
+}
+
+func TestParseCheckerConfig_Invalid(t *testing.T) {
+	g := NewWithT(t)
+	yamlData := []byte(`
+type: DNS
+interval: 10s
+timeout: 5s
+`)
+	_, err := ParseCheckerConfig("dns1", yamlData)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("dnsConfig is required"))
+}
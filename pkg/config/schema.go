@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// durationPattern matches the strings accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|μs|ms|s|m|h))+)$`
+
+// schemaEnums are the allowed values of the string types of the configuration. They are listed here since the constants of a type cannot
+// be found by reflection.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeFor[CheckerType](): {
+		string(CheckTypeDNS), string(CheckTypePodStartup), string(CheckTypeAPIServer), string(CheckTypeMetricsServer), string(CheckTypeAzurePolicy),
+	},
+	reflect.TypeFor[ResultSinkType](): {string(ResultSinkPrometheus), string(ResultSinkLog), string(ResultSinkWebhook), string(ResultSinkEvent)},
+	reflect.TypeFor[OverlapPolicy]():  {string(OverlapPolicySkip), string(OverlapPolicyQueue), string(OverlapPolicyConcurrent)},
+	reflect.TypeFor[DNSCheckTarget](): {string(DNSCheckTargetCoreDNS), string(DNSCheckTargetCoreDNSPerPod), string(DNSCheckTargetLocalDNS)},
+	reflect.TypeFor[CSIType]():        {string(CSITypeAzureFile), string(CSITypeAzureDisk), string(CSITypeAzureBlob)},
+}
+
+// JSONSchema returns a JSON Schema of the configuration file generated from Config, e.g. for validation in editors and CI. Fields without
+// omitempty are required and unknown fields are rejected. The schema only covers the structure of the configuration, ValidateStrict
+// performs the full validation.
+func JSONSchema() ([]byte, error) {
+	schema, err := typeSchema(reflect.TypeFor[Config]())
+	if err != nil {
+		return nil, err
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Cluster Health Monitor configuration"
+	checkers := schema["properties"].(map[string]any)["checkers"].(map[string]any)
+	checkers["minItems"] = 1
+	checkers["maxItems"] = MaxCheckers
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema returns the JSON Schema of a type of the configuration.
+func typeSchema(t reflect.Type) (map[string]any, error) {
+	if t == reflect.TypeFor[time.Duration]() {
+		return map[string]any{"type": "string", "pattern": durationPattern}, nil
+	}
+	if enum, ok := schemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": enum}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Slice:
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		properties := make(map[string]any)
+		required := []string{}
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			fieldSchema, err := typeSchema(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s.%s: %w", t.Name(), field.Name, err)
+			}
+			properties[name] = fieldSchema
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestJSONSchema(t *testing.T) {
+	g := NewWithT(t)
+	data, err := JSONSchema()
+	g.Expect(err).ToNot(HaveOccurred())
+
+	var schema map[string]any
+	g.Expect(json.Unmarshal(data, &schema)).To(Succeed())
+	g.Expect(schema).To(HaveKeyWithValue("type", "object"))
+	g.Expect(schema).To(HaveKeyWithValue("required", ConsistOf("checkers")))
+	g.Expect(schema).To(HaveKeyWithValue("additionalProperties", false))
+
+	properties := schema["properties"].(map[string]any)
+	g.Expect(properties).To(HaveKeyWithValue("resultSinks", HaveKeyWithValue("items", HaveKeyWithValue("enum", ConsistOf("Prometheus", "Log", "Webhook", "Event")))))
+	g.Expect(properties).To(HaveKeyWithValue("webhooks", HaveKeyWithValue("items",
+		HaveKeyWithValue("properties", HaveKeyWithValue("headers", HaveKeyWithValue("additionalProperties", HaveKeyWithValue("type", "string")))))))
+
+	checkers := properties["checkers"].(map[string]any)
+	g.Expect(checkers).To(HaveKeyWithValue("minItems", BeNumerically("==", 1)))
+	g.Expect(checkers).To(HaveKeyWithValue("maxItems", BeNumerically("==", MaxCheckers)))
+	checker := checkers["items"].(map[string]any)
+	g.Expect(checker).To(HaveKeyWithValue("required", ConsistOf("name", "type", "interval", "timeout")))
+	checkerProperties := checker["properties"].(map[string]any)
+	g.Expect(checkerProperties).To(HaveKeyWithValue("runOnStart", HaveKeyWithValue("type", "boolean")))
+	g.Expect(checkerProperties).To(HaveKeyWithValue("maxTargets", HaveKeyWithValue("type", "integer")))
+	g.Expect(checkerProperties).To(HaveKeyWithValue("dnsConfig", HaveKeyWithValue("properties", HaveKeyWithValue("target",
+		HaveKeyWithValue("enum", ConsistOf("CoreDNS", "CoreDNSPerPod", "LocalDNS"))))))
+
+	interval := checkerProperties["interval"].(map[string]any)
+	g.Expect(interval).To(HaveKeyWithValue("type", "string"))
+	pattern := regexp.MustCompile(interval["pattern"].(string))
+	for _, d := range []string{"0", "10s", "1m30s", "1.5h", "500ms"} {
+		g.Expect(pattern.MatchString(d)).To(BeTrue(), d)
+	}
+	for _, d := range []string{"", "10", "10 s", "ten seconds"} {
+		g.Expect(pattern.MatchString(d)).To(BeFalse(), d)
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MaxCheckers is the maximum number of checkers of a configuration file.
+const MaxCheckers = 20
+
+// ValidateStrict validates the configuration in YAML more strictly than ParseFromYAML, e.g. in CI before the configuration is rolled out.
+// In addition to the validation of ParseFromYAML, it rejects unknown fields, more than MaxCheckers checkers, and label keys that are shared
+// by checkers that would delete each other's resources. It returns all errors found, or nil if the configuration is valid, and warnings
+// about settings that are valid but likely unintended, i.e. checkers whose timeout exceeds their interval unless they run concurrently,
+// whose runs are skipped or delayed by the overlap policy whenever they take longer than the interval.
+func ValidateStrict(cfgData []byte) (errs, warnings []error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(cfgData))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return []error{fmt.Errorf("failed to unmarshal yaml: %w", err)}, nil
+		}
+		// The decoder keeps decoding the remaining fields after a type error, so the rest of the configuration is still validated.
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	errs = append(errs, unjoin(cfg.validate())...)
+	if len(cfg.Checkers) > MaxCheckers {
+		errs = append(errs, fmt.Errorf("too many checkers: %d, the max number is %d", len(cfg.Checkers), MaxCheckers))
+	}
+	for _, chk := range cfg.Checkers {
+		if chk.Timeout > chk.Interval && chk.Interval > 0 && chk.OverlapPolicy != OverlapPolicyConcurrent {
+			warnings = append(warnings, fmt.Errorf(
+				"checker %q: timeout %s is greater than interval %s, set overlapPolicy to %q if runs are meant to overlap",
+				chk.Name, chk.Timeout, chk.Interval, OverlapPolicyConcurrent))
+		}
+	}
+	errs = append(errs, labelKeyCollisions(cfg.Checkers)...)
+	return errs, warnings
+}
+
+// labelKeyCollisions returns an error for every label key that marks the resources of several checkers, unless all of them are API server
+// checkers. API server checkers only select the objects whose label value is their name, whereas the pod startup checker deletes all
+// Karpenter NodePools that carry its label key, regardless of the checker that created them.
+func labelKeyCollisions(checkers []CheckerConfig) []error {
+	var keys []string
+	users := make(map[string][]CheckerConfig)
+	for _, chk := range checkers {
+		var key string
+		switch {
+		case chk.Type == CheckTypePodStartup && chk.PodStartupConfig != nil:
+			key = chk.PodStartupConfig.SyntheticPodLabelKey
+		case chk.Type == CheckTypeAPIServer && chk.APIServerConfig != nil:
+			key = chk.APIServerConfig.LabelKey
+		}
+		if key == "" {
+			continue
+		}
+		if _, ok := users[key]; !ok {
+			keys = append(keys, key)
+		}
+		users[key] = append(users[key], chk)
+	}
+
+	var errs []error
+	for _, key := range keys {
+		chks := users[key]
+		if len(chks) < 2 || !slices.ContainsFunc(chks, func(c CheckerConfig) bool { return c.Type != CheckTypeAPIServer }) {
+			continue
+		}
+		names := make([]string, 0, len(chks))
+		for _, c := range chks {
+			names = append(names, fmt.Sprintf("%q", c.Name))
+		}
+		errs = append(errs, fmt.Errorf("label key %q is used by several checkers: %s", key, strings.Join(names, ", ")))
+	}
+	return errs
+}
+
+// unjoin returns the errors joined by errors.Join, or err itself if it was not joined.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
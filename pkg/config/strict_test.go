@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+const strictDNSChecker = `
+  - name: %s
+    type: DNS
+    interval: 10s
+    timeout: 5s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+`
+
+// strictDNSCheckers returns n DNS checkers named dns0 to dns<n-1>.
+func strictDNSCheckers(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, strictDNSChecker, fmt.Sprintf("dns%d", i))
+	}
+	return b.String()
+}
+
+func TestValidateStrict(t *testing.T) {
+	testCases := []struct {
+		name             string
+		yaml             string
+		expected         []string
+		expectedWarnings []string
+	}{
+		{
+			name: "valid",
+			yaml: "checkers:" + strictDNSCheckers(MaxCheckers),
+		},
+		{
+			name: "unknown fields",
+			yaml: `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 5s
+    intervall: 20s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+      port: 53
+`,
+			expected: []string{"field intervall not found", "field port not found"},
+		},
+		{
+			name: "unknown fields and invalid config",
+			yaml: `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 5s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+  - name: dns1
+    type: Ping
+    interval: 10s
+    timeout: 5s
+resultSink: [Log]
+`,
+			expected: []string{"field resultSink not found", "unsupported type: Ping", "duplicate checker name"},
+		},
+		{
+			name: "timeout greater than interval",
+			yaml: `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 15s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+  - name: dns2
+    type: DNS
+    interval: 10s
+    timeout: 15s
+    overlapPolicy: Concurrent
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+`,
+			expectedWarnings: []string{`checker "dns1": timeout 15s is greater than interval 10s`},
+		},
+		{
+			name:     "too many checkers",
+			yaml:     "checkers:" + strictDNSCheckers(MaxCheckers+1),
+			expected: []string{"too many checkers: 21, the max number is 20"},
+		},
+		{
+			name: "label key collisions",
+			yaml: `
+checkers:
+  - name: podstartup1
+    type: PodStartup
+    interval: 1m
+    timeout: 30s
+    podStartupConfig: &podstartup
+      syntheticPodNamespace: default
+      syntheticPodLabelKey: example.com/synthetic
+      syntheticPodStartupTimeout: 5s
+      maxSyntheticPods: 10
+      tcpTimeout: 2s
+  - name: podstartup2
+    type: PodStartup
+    interval: 1m
+    timeout: 30s
+    podStartupConfig: *podstartup
+  - name: apiserver1
+    type: APIServer
+    interval: 1m
+    timeout: 30s
+    apiServerConfig: &apiserver
+      namespace: default
+      labelKey: example.com/apiserver
+      mutateTimeout: 5s
+      readTimeout: 5s
+      maxObjects: 10
+  - name: apiserver2
+    type: APIServer
+    interval: 1m
+    timeout: 30s
+    apiServerConfig: *apiserver
+`,
+			expected: []string{`label key "example.com/synthetic" is used by several checkers: "podstartup1", "podstartup2"`},
+		},
+		{
+			name:     "invalid yaml",
+			yaml:     `checkers: [name: dns1, type: dns`,
+			expected: []string{"failed to unmarshal yaml"},
+		},
+		{
+			name:     "empty",
+			yaml:     "",
+			expected: []string{"at least one checker is required"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			errs, warnings := ValidateStrict([]byte(tc.yaml))
+			g.Expect(errs).To(HaveLen(len(tc.expected)), "errors: %v", errs)
+			for i, expected := range tc.expected {
+				g.Expect(errs[i].Error()).To(ContainSubstring(expected))
+			}
+			g.Expect(warnings).To(HaveLen(len(tc.expectedWarnings)), "warnings: %v", warnings)
+			for i, expected := range tc.expectedWarnings {
+				g.Expect(warnings[i].Error()).To(ContainSubstring(expected))
+			}
+		})
+	}
+}
+
+func TestValidateStrict_Manifests(t *testing.T) {
+	g := NewWithT(t)
+	data, err := os.ReadFile("../../manifests/base/configmap.yaml")
+	g.Expect(err).ToNot(HaveOccurred())
+	var configMap struct {
+		Data map[string]string `yaml:"data"`
+	}
+	g.Expect(yaml.Unmarshal(data, &configMap)).To(Succeed())
+	g.Expect(configMap.Data).To(HaveKey("config.yaml"))
+	errs, warnings := ValidateStrict([]byte(configMap.Data["config.yaml"]))
+	g.Expect(errs).To(BeEmpty())
+	g.Expect(warnings).To(BeEmpty())
+}
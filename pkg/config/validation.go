@@ -3,6 +3,8 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"text/template"
 	"time"
 
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
@@ -30,6 +32,33 @@ func (c *Config) validate() error {
 		nameSet[chk.Name] = struct{}{}
 	}
 
+	sinkSet := make(map[ResultSinkType]struct{})
+	for _, sink := range c.ResultSinks {
+		switch sink {
+		case ResultSinkPrometheus, ResultSinkLog, ResultSinkWebhook, ResultSinkEvent:
+		default:
+			errs = append(errs, fmt.Errorf("invalid result sink: %q", sink))
+		}
+		if _, exists := sinkSet[sink]; exists {
+			errs = append(errs, fmt.Errorf("duplicate result sink: %q", sink))
+		}
+		sinkSet[sink] = struct{}{}
+	}
+
+	webhookSet := make(map[string]struct{})
+	for _, webhook := range c.Webhooks {
+		if err := webhook.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %q: %w", webhook.Name, err))
+		}
+		if _, exists := webhookSet[webhook.Name]; exists {
+			errs = append(errs, fmt.Errorf("duplicate webhook name: %q", webhook.Name))
+		}
+		webhookSet[webhook.Name] = struct{}{}
+	}
+	if _, ok := sinkSet[ResultSinkWebhook]; len(c.Webhooks) > 0 && len(c.ResultSinks) > 0 && !ok {
+		errs = append(errs, fmt.Errorf("webhooks require the %q result sink", ResultSinkWebhook))
+	}
+
 	return errors.Join(errs...)
 }
 
@@ -48,6 +77,32 @@ func (c *CheckerConfig) validate() error {
 	if c.Timeout <= 0 {
 		errs = append(errs, fmt.Errorf("checker config invalid 'timeout': %s", c.Timeout))
 	}
+	if c.Jitter < 0 || c.Interval > 0 && c.Jitter >= c.Interval {
+		errs = append(errs, fmt.Errorf("checker config invalid 'jitter': %s, it must be less than the interval", c.Jitter))
+	}
+	switch c.OverlapPolicy {
+	case "", OverlapPolicySkip, OverlapPolicyQueue, OverlapPolicyConcurrent:
+	default:
+		errs = append(errs, fmt.Errorf("checker config invalid 'overlapPolicy': %s", c.OverlapPolicy))
+	}
+	if c.MaxConcurrentRuns < 0 {
+		errs = append(errs, fmt.Errorf("checker config invalid 'maxConcurrentRuns': %d", c.MaxConcurrentRuns))
+	}
+	if c.FailureThreshold < 0 {
+		errs = append(errs, fmt.Errorf("checker config invalid 'failureThreshold': %d", c.FailureThreshold))
+	}
+	if c.SuccessThreshold < 0 {
+		errs = append(errs, fmt.Errorf("checker config invalid 'successThreshold': %d", c.SuccessThreshold))
+	}
+	if c.TargetGracePeriod < 0 {
+		errs = append(errs, fmt.Errorf("checker config invalid 'targetGracePeriod': %s", c.TargetGracePeriod))
+	}
+	if c.MaxTargets < 0 {
+		errs = append(errs, fmt.Errorf("checker config invalid 'maxTargets': %d", c.MaxTargets))
+	}
+	if err := c.FlapDetection.validate(); err != nil {
+		errs = append(errs, fmt.Errorf("checker config %q FlapDetection validation failed: %w", c.Name, err))
+	}
 
 	switch c.Type {
 	case CheckTypeDNS:
@@ -72,6 +127,51 @@ func (c *CheckerConfig) validate() error {
 	return errors.Join(errs...)
 }
 
+// validate validates the FlapDetectionConfig. A nil config is valid as flap detection is optional.
+func (c *FlapDetectionConfig) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	var errs []error
+	if c.MaxTransitions <= 0 {
+		errs = append(errs, fmt.Errorf("maxTransitions must be greater than 0"))
+	}
+	if c.Window <= 0 {
+		errs = append(errs, fmt.Errorf("window must be greater than 0"))
+	}
+	return errors.Join(errs...)
+}
+
+// validate validates the WebhookConfig.
+func (c *WebhookConfig) validate() error {
+	var errs []error
+	if c.Name == "" {
+		errs = append(errs, fmt.Errorf("webhook config missing 'name'"))
+	}
+	if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("webhook config invalid 'url': %q, it must be an http or https URL", c.URL))
+	}
+	if c.BodyTemplate != "" {
+		if _, err := template.New(c.Name).Funcs(WebhookTemplateFuncs).Parse(c.BodyTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("webhook config invalid 'bodyTemplate': %w", err))
+		}
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("webhook config invalid 'timeout': %s", c.Timeout))
+	}
+	if c.MaxRetries < 0 {
+		errs = append(errs, fmt.Errorf("webhook config invalid 'maxRetries': %d", c.MaxRetries))
+	}
+	if c.RetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("webhook config invalid 'retryBackoff': %s", c.RetryBackoff))
+	}
+	if c.DedupeWindow < 0 {
+		errs = append(errs, fmt.Errorf("webhook config invalid 'dedupeWindow': %s", c.DedupeWindow))
+	}
+	return errors.Join(errs...)
+}
+
 // validate validates the DNSConfig.
 func (c *DNSConfig) validate(checkerConfigTimeout time.Duration) error {
 	if c == nil {
@@ -58,6 +58,110 @@ func TestConfigValidate_DuplicateNames(t *testing.T) {
 	g.Expect(err.Error()).To(ContainSubstring("duplicate checker name"))
 }
 
+func TestConfigValidate_ResultSinks(t *testing.T) {
+	testCases := []struct {
+		name          string
+		sinks         []ResultSinkType
+		expectedError string
+	}{
+		{name: "default sinks"},
+		{name: "single sink", sinks: []ResultSinkType{ResultSinkLog}},
+		{name: "unknown sink", sinks: []ResultSinkType{"File"}, expectedError: "invalid result sink"},
+		{name: "duplicate sink", sinks: []ResultSinkType{ResultSinkPrometheus, ResultSinkPrometheus}, expectedError: "duplicate result sink"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cfg := &Config{
+				Checkers: []CheckerConfig{
+					{Name: "foo", Type: CheckTypeMetricsServer, Interval: 10 * time.Second, Timeout: 5 * time.Second},
+				},
+				ResultSinks: tc.sinks,
+			}
+			err := cfg.validate()
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+			}
+		})
+	}
+}
+
+func TestConfigValidate_Webhooks(t *testing.T) {
+	valid := WebhookConfig{Name: "slack", URL: "https://hooks.example.com/services/abc"}
+	testCases := []struct {
+		name          string
+		webhooks      []WebhookConfig
+		sinks         []ResultSinkType
+		expectedError string
+	}{
+		{
+			name: "valid webhook",
+			webhooks: []WebhookConfig{{
+				Name:         "slack",
+				URL:          "https://hooks.example.com/services/abc",
+				Checkers:     []string{"dns"},
+				BodyTemplate: `{"text": {{ json .Message }}}`,
+				MaxRetries:   3,
+				RetryBackoff: time.Second,
+				DedupeWindow: time.Minute,
+			}},
+		},
+		{
+			name:          "missing name and invalid url",
+			webhooks:      []WebhookConfig{{URL: "hooks.example.com"}},
+			expectedError: "missing 'name'",
+		},
+		{
+			name:          "unsupported url scheme",
+			webhooks:      []WebhookConfig{{Name: "ftp", URL: "ftp://example.com"}},
+			expectedError: "invalid 'url'",
+		},
+		{
+			name:          "invalid template",
+			webhooks:      []WebhookConfig{{Name: "bad", URL: "http://example.com", BodyTemplate: "{{ .Message"}},
+			expectedError: "invalid 'bodyTemplate'",
+		},
+		{
+			name:          "negative retries",
+			webhooks:      []WebhookConfig{{Name: "bad", URL: "http://example.com", MaxRetries: -1, DedupeWindow: -1}},
+			expectedError: "invalid 'maxRetries'",
+		},
+		{
+			name:          "duplicate name",
+			webhooks:      []WebhookConfig{valid, valid},
+			expectedError: "duplicate webhook name",
+		},
+		{
+			name:          "webhook sink not selected",
+			webhooks:      []WebhookConfig{valid},
+			sinks:         []ResultSinkType{ResultSinkPrometheus},
+			expectedError: "webhooks require the \"Webhook\" result sink",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			cfg := &Config{
+				Checkers: []CheckerConfig{
+					{Name: "foo", Type: CheckTypeMetricsServer, Interval: 10 * time.Second, Timeout: 5 * time.Second},
+				},
+				ResultSinks: tc.sinks,
+				Webhooks:    tc.webhooks,
+			}
+			err := cfg.validate()
+			if tc.expectedError == "" {
+				g.Expect(err).ToNot(HaveOccurred())
+			} else {
+				g.Expect(err).To(MatchError(ContainSubstring(tc.expectedError)))
+			}
+		})
+	}
+}
+
 func TestCheckerConfigValidate_MissingFields(t *testing.T) {
 	g := NewWithT(t)
 	chk := CheckerConfig{}
@@ -77,6 +181,47 @@ func TestCheckerConfigValidate_UnsupportedType(t *testing.T) {
 	g.Expect(err.Error()).To(ContainSubstring("unsupported type"))
 }
 
+func TestCheckerConfigValidate_OptionalFields(t *testing.T) {
+	g := NewWithT(t)
+	chk := CheckerConfig{
+		Name:              "foo",
+		Type:              CheckTypeMetricsServer,
+		Interval:          1,
+		Timeout:           1,
+		Jitter:            1,
+		OverlapPolicy:     "Never",
+		MaxConcurrentRuns: -1,
+		FailureThreshold:  -1,
+		SuccessThreshold:  -1,
+		FlapDetection:     &FlapDetectionConfig{},
+		TargetGracePeriod: -1,
+		MaxTargets:        -1,
+	}
+	err := chk.validate()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'jitter'"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'overlapPolicy'"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'maxConcurrentRuns'"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'failureThreshold'"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'successThreshold'"))
+	g.Expect(err.Error()).To(ContainSubstring("maxTransitions must be greater than 0"))
+	g.Expect(err.Error()).To(ContainSubstring("window must be greater than 0"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'targetGracePeriod'"))
+	g.Expect(err.Error()).To(ContainSubstring("invalid 'maxTargets'"))
+
+	chk.Interval = time.Minute
+	chk.Timeout = time.Second
+	chk.Jitter = 5 * time.Second
+	chk.OverlapPolicy = OverlapPolicyConcurrent
+	chk.MaxConcurrentRuns = 3
+	chk.FailureThreshold = 3
+	chk.SuccessThreshold = 0
+	chk.FlapDetection = &FlapDetectionConfig{MaxTransitions: 4, Window: 10 * time.Minute}
+	chk.TargetGracePeriod = 0
+	chk.MaxTargets = 10
+	g.Expect(chk.validate()).To(Succeed())
+}
+
 func TestPodStartupConfig_Validate(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"k8s.io/klog/v2"
+)
+
+// FileWatcher watches a configuration file for changes. The file is polled rather than watched with inotify because Kubernetes updates
+// mounted ConfigMaps by swapping symlinks, which file notifications do not reliably report.
+type FileWatcher struct {
+	path     string
+	interval time.Duration
+	// lastData is the content of the last accepted configuration.
+	lastData []byte
+	// invalidData is the content of the last configuration that failed validation. It is not parsed again until the file changes.
+	invalidData []byte
+}
+
+// NewFileWatcher creates a FileWatcher that polls the file at path every interval.
+func NewFileWatcher(path string, interval time.Duration) *FileWatcher {
+	return &FileWatcher{
+		path:     path,
+		interval: interval,
+	}
+}
+
+// Load reads and parses the configuration file. The content is remembered so that Run only reports subsequent changes.
+func (w *FileWatcher) Load() (*Config, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %q: %w", w.path, err)
+	}
+	cfg, err := ParseFromYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	w.lastData = data
+	return cfg, nil
+}
+
+// Run polls the configuration file until ctx is done and calls onChange with the new configuration whenever the content of the file
+// changes and passes validation. If onChange returns an error, the configuration is treated as rejected and applying it is retried on
+// the next poll.
+func (w *FileWatcher) Run(ctx context.Context, onChange func(*Config) error) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	klog.InfoS("Started config file watcher", "path", w.path, "interval", w.interval.String())
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := w.poll(onChange)
+			if err != nil {
+				metrics.ConfigReloadCounter.WithLabelValues(metrics.ReloadFailure).Inc()
+				klog.ErrorS(err, "Rejected config change, keeping the previous config", "path", w.path)
+				continue
+			}
+			if changed {
+				metrics.ConfigReloadCounter.WithLabelValues(metrics.ReloadSuccess).Inc()
+				klog.InfoS("Applied config change", "path", w.path)
+			}
+		case <-ctx.Done():
+			klog.InfoS("Stopped config file watcher", "path", w.path)
+			return ctx.Err()
+		}
+	}
+}
+
+// poll reads the configuration file and calls onChange if its content has changed since the last accepted configuration. It returns
+// whether a new configuration was applied.
+func (w *FileWatcher) poll(onChange func(*Config) error) (bool, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config file %q: %w", w.path, err)
+	}
+	if bytes.Equal(data, w.lastData) || bytes.Equal(data, w.invalidData) {
+		return false, nil
+	}
+	cfg, err := ParseFromYAML(data)
+	if err != nil {
+		w.invalidData = data
+		return false, err
+	}
+	if err := onChange(cfg); err != nil {
+		return false, fmt.Errorf("failed to apply config: %w", err)
+	}
+	w.lastData = data
+	w.invalidData = nil
+	return true, nil
+}
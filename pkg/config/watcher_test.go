@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const watcherTestConfig = `
+checkers:
+  - name: dns1
+    type: DNS
+    interval: 10s
+    timeout: 5s
+    dnsConfig:
+      domain: example.com
+      queryTimeout: 2s
+      target: CoreDNS
+`
+
+func TestFileWatcher_poll(t *testing.T) {
+	g := NewWithT(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	g.Expect(os.WriteFile(path, []byte(watcherTestConfig), 0600)).To(Succeed())
+
+	w := NewFileWatcher(path, 0)
+	cfg, err := w.Load()
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(cfg.Checkers).To(HaveLen(1))
+
+	var applied []*Config
+	onChange := func(cfg *Config) error {
+		applied = append(applied, cfg)
+		return nil
+	}
+
+	// Unchanged file is not reported.
+	changed, err := w.poll(onChange)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(applied).To(BeEmpty())
+
+	// Invalid config is rejected once and not parsed again until the file changes.
+	g.Expect(os.WriteFile(path, []byte(`checkers: []`), 0600)).To(Succeed())
+	changed, err = w.poll(onChange)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	changed, err = w.poll(onChange)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	g.Expect(applied).To(BeEmpty())
+
+	// Valid change is applied.
+	g.Expect(os.WriteFile(path, []byte(watcherTestConfig+"      \n"), 0600)).To(Succeed())
+	changed, err = w.poll(onChange)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(applied).To(HaveLen(1))
+	g.Expect(applied[0].Checkers[0].Name).To(Equal("dns1"))
+
+	// Change rejected by onChange is retried on the next poll.
+	g.Expect(os.WriteFile(path, []byte(watcherTestConfig), 0600)).To(Succeed())
+	changed, err = w.poll(func(*Config) error { return errors.New("apply error") })
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(changed).To(BeFalse())
+	changed, err = w.poll(onChange)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(applied).To(HaveLen(2))
+}
+
+func TestFileWatcher_Load_NotExist(t *testing.T) {
+	g := NewWithT(t)
+	w := NewFileWatcher("/tmp/does-not-exist.yaml", 0)
+	_, err := w.Load()
+	g.Expect(err).To(HaveOccurred())
+}
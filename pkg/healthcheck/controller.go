@@ -0,0 +1,386 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	"github.com/Azure/cluster-health-monitor/pkg/status"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// Controller reconciles HealthCheck resources into checker schedules of a scheduler, and periodically writes the latest result of each
+// checker to the status of its HealthCheck. Checkers are keyed by the name of their HealthCheck, which must not collide with the name of
+// a checker from the configuration file.
+type Controller struct {
+	dynamicClient      dynamic.Interface
+	cluster            *checker.Cluster
+	sched              *scheduler.Scheduler
+	statusSyncInterval time.Duration
+	// build builds a checker from its config, it is checker.Build outside of tests.
+	build func(cfg *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, func(), error)
+
+	informerFactory dynamicinformer.DynamicSharedInformerFactory
+	informer        cache.SharedIndexInformer
+	queue           workqueue.TypedRateLimitingInterface[string]
+
+	// isLeader reports whether this replica writes the status of HealthChecks. All replicas do if it is nil.
+	isLeader func() bool
+
+	// checkers holds the state of each HealthCheck, keyed by name. It is only accessed by the single worker.
+	checkers map[string]*managedChecker
+}
+
+// managedChecker is the state of the checker of a HealthCheck.
+type managedChecker struct {
+	// cfg is the parsed spec. It is nil if the spec is invalid.
+	cfg *config.CheckerConfig
+	// chk is the scheduled checker. It is nil if the checker is not scheduled.
+	chk checker.Checker
+	// configured is the config whose thresholds, target limits or skipped record the controller applied. It is nil if the controller has
+	// not applied any, e.g. because the name of the HealthCheck is taken by a checker of the configuration file, whose state must be kept.
+	configured *config.CheckerConfig
+	// accepted is the Accepted condition of the HealthCheck.
+	accepted metav1.Condition
+}
+
+// NewController creates a controller that schedules the checkers of HealthCheck resources with sched, building them for cluster. The
+// status of every HealthCheck is refreshed every statusSyncInterval.
+func NewController(dynamicClient dynamic.Interface, cluster *checker.Cluster, sched *scheduler.Scheduler,
+	statusSyncInterval time.Duration) (*Controller, error) {
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+	c := &Controller{
+		dynamicClient:      dynamicClient,
+		cluster:            cluster,
+		sched:              sched,
+		statusSyncInterval: statusSyncInterval,
+		build:              checker.Build,
+		informerFactory:    factory,
+		informer:           factory.ForResource(GVR).Informer(),
+		queue:              workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[string]()),
+		checkers:           make(map[string]*managedChecker),
+	}
+	_, err := c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: c.enqueue,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add HealthCheck event handler: %w", err)
+	}
+	return c, nil
+}
+
+// SetIsLeader makes the controller only write the status of HealthChecks while isLeader returns true. Replicas that are not the leader
+// may skip runs of checkers that create resources, so their results must not overwrite the results of the leader.
+func (c *Controller) SetIsLeader(isLeader func() bool) {
+	c.isLeader = isLeader
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get HealthCheck key")
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Run starts the controller and blocks until ctx is canceled.
+func (c *Controller) Run(ctx context.Context) error {
+	defer c.queue.ShutDown()
+
+	c.informerFactory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), c.informer.HasSynced) {
+		return fmt.Errorf("failed to sync HealthCheck informer: %w", ctx.Err())
+	}
+	klog.InfoS("Started HealthCheck controller")
+
+	go func() {
+		for c.processNextItem(ctx) {
+		}
+	}()
+
+	ticker := time.NewTicker(c.statusSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			for _, key := range c.informer.GetStore().ListKeys() {
+				c.queue.Add(key)
+			}
+		}
+	}
+}
+
+func (c *Controller) processNextItem(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.reconcile(ctx, key); err != nil {
+		klog.ErrorS(err, "Failed to reconcile HealthCheck", "name", key)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// reconcile schedules, reschedules or removes the checker of the named HealthCheck and updates its status.
+func (c *Controller) reconcile(ctx context.Context, name string) error {
+	obj, exists, err := c.informer.GetStore().GetByKey(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		c.remove(name)
+		return nil
+	}
+	hc, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected HealthCheck object type %T", obj)
+	}
+	mc := c.apply(hc)
+	return c.updateStatus(ctx, hc, mc)
+}
+
+// apply parses the spec of a HealthCheck and schedules its checker if the spec changed. A checker that failed to build or be scheduled
+// is retried on every reconcile.
+func (c *Controller) apply(hc *unstructured.Unstructured) *managedChecker {
+	name := hc.GetName()
+	mc, ok := c.checkers[name]
+	if !ok {
+		mc = &managedChecker{}
+		c.checkers[name] = mc
+	}
+
+	cfg, err := parseSpec(hc)
+	if err != nil {
+		c.release(mc)
+		mc.cfg = nil
+		mc.accepted = newCondition(ConditionAccepted, metav1.ConditionFalse, ReasonInvalidSpec, err.Error())
+		return mc
+	}
+	if reflect.DeepEqual(mc.cfg, cfg) && (mc.chk != nil || mc.accepted.Reason == ReasonSkipped) {
+		return mc
+	}
+
+	if mc.chk == nil && c.isScheduled(name) {
+		// The name is taken by a checker of the configuration file.
+		mc.cfg = cfg
+		mc.accepted = newCondition(ConditionAccepted, metav1.ConditionFalse, ReasonNameConflict,
+			fmt.Sprintf("A checker named %q is already configured in the configuration file", name))
+		return mc
+	}
+
+	chk, configure, err := c.build(cfg, c.cluster)
+	c.unschedule(mc)
+	mc.cfg = cfg
+	switch {
+	case errors.Is(err, checker.ErrSkipChecker):
+		configure()
+		mc.configured = cfg
+		mc.accepted = newCondition(ConditionAccepted, metav1.ConditionFalse, ReasonSkipped, err.Error())
+	case err != nil:
+		mc.accepted = newCondition(ConditionAccepted, metav1.ConditionFalse, ReasonBuildFailed, err.Error())
+	default:
+		err := c.sched.Add(scheduler.CheckerSchedule{
+			Interval:          cfg.Interval,
+			Timeout:           cfg.Timeout,
+			RunOnStart:        cfg.RunOnStart,
+			Jitter:            cfg.Jitter,
+			OverlapPolicy:     cfg.OverlapPolicy,
+			MaxConcurrentRuns: cfg.MaxConcurrentRuns,
+			Checker:           chk,
+		})
+		if err != nil {
+			mc.accepted = newCondition(ConditionAccepted, metav1.ConditionFalse, ReasonScheduleFailed, err.Error())
+			break
+		}
+		configure()
+		mc.chk = chk
+		mc.configured = cfg
+		mc.accepted = newCondition(ConditionAccepted, metav1.ConditionTrue, ReasonScheduled, "Checker is scheduled")
+		klog.InfoS("Scheduled HealthCheck checker", "name", name, "type", cfg.Type)
+	}
+	return mc
+}
+
+// unschedule removes the schedule of a checker, if it is scheduled.
+func (c *Controller) unschedule(mc *managedChecker) {
+	if mc.chk == nil {
+		return
+	}
+	c.sched.Remove(mc.chk.Name())
+	mc.chk = nil
+}
+
+// release removes the schedule of a checker, if it is scheduled, and clears the state the controller applied for it.
+func (c *Controller) release(mc *managedChecker) {
+	c.unschedule(mc)
+	if mc.configured != nil {
+		checker.ClearChecker(c.cluster.Name, mc.configured)
+		mc.configured = nil
+	}
+}
+
+// isScheduled returns whether a checker of the given name is scheduled.
+func (c *Controller) isScheduled(name string) bool {
+	for _, chk := range c.sched.Checkers() {
+		if chk.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes the checker of a deleted HealthCheck.
+func (c *Controller) remove(name string) {
+	mc, ok := c.checkers[name]
+	if !ok {
+		return
+	}
+	c.release(mc)
+	delete(c.checkers, name)
+	klog.InfoS("Removed HealthCheck checker", "name", name)
+}
+
+// parseSpec parses and validates the spec of a HealthCheck.
+func parseSpec(hc *unstructured.Unstructured) (*config.CheckerConfig, error) {
+	spec, _, err := unstructured.NestedMap(hc.Object, "spec")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	data, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spec: %w", err)
+	}
+	return config.ParseCheckerConfig(hc.GetName(), data)
+}
+
+// updateStatus writes the Accepted condition and the latest result of the checker to the status of a HealthCheck, if they changed.
+func (c *Controller) updateStatus(ctx context.Context, hc *unstructured.Unstructured, mc *managedChecker) error {
+	if c.isLeader != nil && !c.isLeader() {
+		return nil
+	}
+
+	var current Status
+	if obj, ok := hc.Object["status"].(map[string]interface{}); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &current); err != nil {
+			klog.ErrorS(err, "Failed to parse HealthCheck status, overwriting it", "name", hc.GetName())
+		}
+	}
+
+	generation := hc.GetGeneration()
+	desired := Status{
+		ObservedGeneration: generation,
+		Conditions:         current.Conditions,
+	}
+	accepted := mc.accepted
+	accepted.ObservedGeneration = generation
+	meta.SetStatusCondition(&desired.Conditions, accepted)
+	if mc.chk != nil {
+		result := status.NewResponse(c.cluster.Name, []checker.Checker{mc.chk}).Checkers[0]
+		if result.Status != "" || len(result.Targets) > 0 {
+			desired.Result = &result
+		}
+	}
+	healthy := healthyCondition(desired.Result)
+	healthy.ObservedGeneration = generation
+	meta.SetStatusCondition(&desired.Conditions, healthy)
+	desired.LastTransitionTime = &meta.FindStatusCondition(desired.Conditions, ConditionHealthy).LastTransitionTime
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&desired)
+	if err != nil {
+		return fmt.Errorf("failed to convert status: %w", err)
+	}
+	if reflect.DeepEqual(hc.Object["status"], obj) {
+		return nil
+	}
+	updated := hc.DeepCopy()
+	updated.Object["status"] = obj
+	if _, err := c.dynamicClient.Resource(GVR).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update status: %w", err)
+	}
+	return nil
+}
+
+// healthyCondition returns the Healthy condition for the latest result of a checker. For per-target checkers, the worst target status is
+// used.
+func healthyCondition(result *status.CheckerStatus) metav1.Condition {
+	if result == nil {
+		return newCondition(ConditionHealthy, metav1.ConditionUnknown, ReasonNoResult, "Checker has not recorded a result yet")
+	}
+
+	resultStatus, message := result.Status, detailMessage(result.ErrorCode, result.Message)
+	if resultStatus == "" {
+		// Per-target checkers only record target results, report the worst of them.
+		for _, target := range result.Targets {
+			if resultStatus == "" || statusSeverity(target.Status) > statusSeverity(resultStatus) {
+				resultStatus = target.Status
+				message = fmt.Sprintf("Target %s: %s", target.Name, detailMessage(target.ErrorCode, target.Message))
+			}
+		}
+	}
+
+	conditionStatus := metav1.ConditionUnknown
+	switch resultStatus {
+	case metrics.HealthyStatus:
+		conditionStatus = metav1.ConditionTrue
+		message = "Checker is healthy"
+	case metrics.UnhealthyStatus:
+		conditionStatus = metav1.ConditionFalse
+	}
+	return newCondition(ConditionHealthy, conditionStatus, resultStatus, message)
+}
+
+// statusSeverity orders result statuses from healthy to unhealthy.
+func statusSeverity(resultStatus string) int {
+	switch resultStatus {
+	case metrics.HealthyStatus:
+		return 0
+	case metrics.SkippedStatus:
+		return 1
+	case metrics.UnknownStatus:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// detailMessage returns the message of a result, prefixed with its error code if it has one.
+func detailMessage(code, message string) string {
+	if code == "" {
+		return message
+	}
+	return fmt.Sprintf("[%s] %s", code, message)
+}
+
+func newCondition(conditionType string, conditionStatus metav1.ConditionStatus, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionType,
+		Status:  conditionStatus,
+		Reason:  reason,
+		Message: message,
+	}
+}
@@ -0,0 +1,276 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	"github.com/Azure/cluster-health-monitor/pkg/status"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeChecker struct {
+	name    string
+	chkType config.CheckerType
+}
+
+func (f *fakeChecker) Name() string             { return f.name }
+func (f *fakeChecker) Type() config.CheckerType { return f.chkType }
+func (f *fakeChecker) Run(ctx context.Context)  {}
+
+func fakeBuilder(cfg *config.CheckerConfig, cluster *checker.Cluster) (checker.Checker, func(), error) {
+	if cfg.Type == config.CheckTypeAzurePolicy {
+		return nil, func() {}, checker.ErrSkipChecker
+	}
+	if cfg.Type == config.CheckTypePodStartup {
+		return nil, nil, errors.New("build failed")
+	}
+	return &fakeChecker{name: cfg.Name, chkType: cfg.Type}, func() {}, nil
+}
+
+func newHealthCheck(name string, spec map[string]interface{}) *unstructured.Unstructured {
+	hc := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	hc.SetAPIVersion(GVR.GroupVersion().String())
+	hc.SetKind("HealthCheck")
+	hc.SetName(name)
+	hc.SetGeneration(1)
+	return hc
+}
+
+func metricsServerSpec(interval string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "MetricsServer",
+		"interval": interval,
+		"timeout":  "5s",
+	}
+}
+
+// newTestController returns a controller whose informer store and fake client hold the given HealthChecks.
+func newTestController(g *WithT, sched *scheduler.Scheduler, hcs ...*unstructured.Unstructured) *Controller {
+	var objs []runtime.Object
+	for _, hc := range hcs {
+		objs = append(objs, hc)
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{GVR: "HealthCheckList"}, objs...)
+	c, err := NewController(dynamicClient, &checker.Cluster{KubeClient: k8sfake.NewClientset()}, sched, time.Second)
+	g.Expect(err).ToNot(HaveOccurred())
+	c.build = fakeBuilder
+	for _, hc := range hcs {
+		g.Expect(c.informer.GetStore().Add(hc)).To(Succeed())
+	}
+	return c
+}
+
+// getStatus returns the status of a HealthCheck from the fake client.
+func getStatus(g *WithT, c *Controller, name string) Status {
+	hc, err := c.dynamicClient.Resource(GVR).Get(context.Background(), name, metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	var s Status
+	g.Expect(runtime.DefaultUnstructuredConverter.FromUnstructured(hc.Object["status"].(map[string]interface{}), &s)).To(Succeed())
+	return s
+}
+
+func TestController_Reconcile(t *testing.T) {
+	testCases := []struct {
+		name              string
+		spec              map[string]interface{}
+		expectedScheduled bool
+		expectedReason    string
+	}{
+		{
+			name:              "valid spec is scheduled",
+			spec:              metricsServerSpec("10s"),
+			expectedScheduled: true,
+			expectedReason:    ReasonScheduled,
+		},
+		{
+			name:           "invalid spec is rejected",
+			spec:           map[string]interface{}{"type": "DNS", "interval": "10s", "timeout": "5s"},
+			expectedReason: ReasonInvalidSpec,
+		},
+		{
+			name:           "skipped checker is not scheduled",
+			spec:           map[string]interface{}{"type": "AzurePolicy", "interval": "10s", "timeout": "5s"},
+			expectedReason: ReasonSkipped,
+		},
+		{
+			name: "build failure is reported",
+			spec: map[string]interface{}{
+				"type":     "PodStartup",
+				"interval": "1m",
+				"timeout":  "30s",
+				"podStartupConfig": map[string]interface{}{
+					"syntheticPodNamespace":      "kube-system",
+					"syntheticPodLabelKey":       "example.com/synthetic",
+					"syntheticPodStartupTimeout": "5s",
+					"maxSyntheticPods":           int64(5),
+					"tcpTimeout":                 "2s",
+				},
+			},
+			expectedReason: ReasonBuildFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			sched := scheduler.NewScheduler(nil)
+			c := newTestController(g, sched, newHealthCheck("hc", tc.spec))
+
+			g.Expect(c.reconcile(context.Background(), "hc")).To(Succeed())
+
+			if tc.expectedScheduled {
+				g.Expect(sched.Checkers()).To(HaveLen(1))
+				g.Expect(sched.Checkers()[0].Name()).To(Equal("hc"))
+			} else {
+				g.Expect(sched.Checkers()).To(BeEmpty())
+			}
+			s := getStatus(g, c, "hc")
+			g.Expect(s.ObservedGeneration).To(Equal(int64(1)))
+			accepted := meta.FindStatusCondition(s.Conditions, ConditionAccepted)
+			g.Expect(accepted).ToNot(BeNil())
+			g.Expect(accepted.Reason).To(Equal(tc.expectedReason))
+			g.Expect(accepted.Status == metav1.ConditionTrue).To(Equal(tc.expectedScheduled))
+			healthy := meta.FindStatusCondition(s.Conditions, ConditionHealthy)
+			g.Expect(healthy).ToNot(BeNil())
+			g.Expect(healthy.Reason).To(Equal(ReasonNoResult))
+		})
+	}
+}
+
+func TestController_UpdateAndDelete(t *testing.T) {
+	g := NewWithT(t)
+	sched := scheduler.NewScheduler(nil)
+	hc := newHealthCheck("hc-update", metricsServerSpec("10s"))
+	c := newTestController(g, sched, hc)
+	g.Expect(c.reconcile(context.Background(), "hc-update")).To(Succeed())
+	first := sched.Checkers()[0]
+
+	// An unchanged spec keeps the scheduled checker.
+	g.Expect(c.reconcile(context.Background(), "hc-update")).To(Succeed())
+	g.Expect(sched.Checkers()[0]).To(BeIdenticalTo(first))
+
+	// A changed spec reschedules the checker.
+	updated := newHealthCheck("hc-update", metricsServerSpec("20s"))
+	g.Expect(c.informer.GetStore().Update(updated)).To(Succeed())
+	g.Expect(c.reconcile(context.Background(), "hc-update")).To(Succeed())
+	g.Expect(sched.Checkers()).To(HaveLen(1))
+	g.Expect(sched.Checkers()[0]).ToNot(BeIdenticalTo(first))
+
+	// A deleted HealthCheck removes the checker.
+	g.Expect(c.informer.GetStore().Delete(updated)).To(Succeed())
+	g.Expect(c.reconcile(context.Background(), "hc-update")).To(Succeed())
+	g.Expect(sched.Checkers()).To(BeEmpty())
+	g.Expect(c.checkers).To(BeEmpty())
+}
+
+func TestController_NameConflict(t *testing.T) {
+	g := NewWithT(t)
+	fileChecker := &fakeChecker{name: "hc-conflict", chkType: config.CheckTypeMetricsServer}
+	sched := scheduler.NewScheduler([]scheduler.CheckerSchedule{{Interval: time.Minute, Timeout: time.Second, Checker: fileChecker}})
+	checker.RecordResult(context.Background(), fileChecker, checker.Healthy(), nil)
+	t.Cleanup(func() {
+		checker.ClearChecker("", &config.CheckerConfig{Name: "hc-conflict", Type: config.CheckTypeMetricsServer})
+	})
+
+	hc := newHealthCheck("hc-conflict", metricsServerSpec("10s"))
+	c := newTestController(g, sched, hc)
+	g.Expect(c.reconcile(context.Background(), "hc-conflict")).To(Succeed())
+
+	accepted := meta.FindStatusCondition(getStatus(g, c, "hc-conflict").Conditions, ConditionAccepted)
+	g.Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(accepted.Reason).To(Equal(ReasonNameConflict))
+	g.Expect(sched.Checkers()).To(ConsistOf(BeIdenticalTo(fileChecker)))
+
+	// Deleting the HealthCheck keeps the checker of the configuration file and its results.
+	g.Expect(c.informer.GetStore().Delete(hc)).To(Succeed())
+	g.Expect(c.reconcile(context.Background(), "hc-conflict")).To(Succeed())
+	g.Expect(sched.Checkers()).To(ConsistOf(BeIdenticalTo(fileChecker)))
+	_, ok := checker.LatestResult("", "hc-conflict")
+	g.Expect(ok).To(BeTrue())
+}
+
+func TestController_StatusResult(t *testing.T) {
+	g := NewWithT(t)
+	sched := scheduler.NewScheduler(nil)
+	c := newTestController(g, sched, newHealthCheck("hc-result", metricsServerSpec("10s")))
+	g.Expect(c.reconcile(context.Background(), "hc-result")).To(Succeed())
+
+	chk := sched.Checkers()[0]
+	checker.RecordResult(context.Background(), chk, checker.Unhealthy("METRICS_UNAVAILABLE", "metrics API unavailable"), nil)
+
+	// The informer store is not running in tests, refresh it from the fake client.
+	hc, err := c.dynamicClient.Resource(GVR).Get(context.Background(), "hc-result", metav1.GetOptions{})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(c.informer.GetStore().Update(hc)).To(Succeed())
+	g.Expect(c.reconcile(context.Background(), "hc-result")).To(Succeed())
+
+	s := getStatus(g, c, "hc-result")
+	g.Expect(s.Result).ToNot(BeNil())
+	g.Expect(s.Result.Status).To(Equal("Unhealthy"))
+	g.Expect(s.Result.ErrorCode).To(Equal("METRICS_UNAVAILABLE"))
+	healthy := meta.FindStatusCondition(s.Conditions, ConditionHealthy)
+	g.Expect(healthy.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(healthy.Message).To(Equal("[METRICS_UNAVAILABLE] metrics API unavailable"))
+	g.Expect(s.LastTransitionTime).ToNot(BeNil())
+	g.Expect(s.LastTransitionTime.Time).To(Equal(healthy.LastTransitionTime.Time))
+}
+
+func TestHealthyCondition(t *testing.T) {
+	testCases := []struct {
+		name           string
+		result         *status.CheckerStatus
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "no result",
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: ReasonNoResult,
+		},
+		{
+			name:           "healthy",
+			result:         &status.CheckerStatus{Status: "Healthy"},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: "Healthy",
+		},
+		{
+			name:           "skipped",
+			result:         &status.CheckerStatus{Status: "Skipped", ErrorCode: "NOT_APPLICABLE"},
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "Skipped",
+		},
+		{
+			name: "worst target status",
+			result: &status.CheckerStatus{Targets: []status.TargetStatus{
+				{Name: "coredns-1", Status: "Healthy"},
+				{Name: "coredns-2", Status: "Unhealthy", ErrorCode: "POD_TIMEOUT"},
+				{Name: "coredns-3", Status: "Unknown"},
+			}},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: "Unhealthy",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			condition := healthyCondition(tc.result)
+			g.Expect(condition.Type).To(Equal(ConditionHealthy))
+			g.Expect(condition.Status).To(Equal(tc.expectedStatus))
+			g.Expect(condition.Reason).To(Equal(tc.expectedReason))
+		})
+	}
+}
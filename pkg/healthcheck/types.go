@@ -0,0 +1,50 @@
+// Package healthcheck reconciles HealthCheck custom resources into scheduled checkers.
+package healthcheck
+
+import (
+	"github.com/Azure/cluster-health-monitor/pkg/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GVR is the group, version and resource of the cluster-scoped HealthCheck custom resource. The spec of a HealthCheck mirrors
+// config.CheckerConfig without the name, which is taken from the name of the resource.
+var GVR = schema.GroupVersionResource{
+	Group:    "clusterhealthmonitor.azure.com",
+	Version:  "v1alpha1",
+	Resource: "healthchecks",
+}
+
+// Condition types of a HealthCheck.
+const (
+	// ConditionAccepted is true if the spec of the HealthCheck is valid and its checker is scheduled.
+	ConditionAccepted = "Accepted"
+	// ConditionHealthy is true if the latest result of the checker is healthy, false if it is unhealthy and unknown otherwise.
+	ConditionHealthy = "Healthy"
+)
+
+// Reasons of the Accepted condition.
+const (
+	ReasonScheduled      = "Scheduled"
+	ReasonInvalidSpec    = "InvalidSpec"
+	ReasonBuildFailed    = "BuildFailed"
+	ReasonSkipped        = "Skipped"
+	ReasonScheduleFailed = "ScheduleFailed"
+	ReasonNameConflict   = "NameConflict"
+)
+
+// ReasonNoResult is the reason of the Healthy condition until the checker records a result. Otherwise the reason is the status of the
+// latest result.
+const ReasonNoResult = "NoResult"
+
+// Status is the status of a HealthCheck.
+type Status struct {
+	// ObservedGeneration is the generation of the spec the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions holds the Accepted and Healthy conditions.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Result is the latest result of the checker, in the same format as the status API.
+	Result *status.CheckerStatus `json:"result,omitempty"`
+	// LastTransitionTime is the time at which the Healthy condition last changed.
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
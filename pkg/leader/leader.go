@@ -0,0 +1,108 @@
+// Package leader provides Lease-based leader election, so that only one replica of the monitor runs the checkers that create resources.
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/klog/v2"
+)
+
+// Config is the configuration of the leader election.
+type Config struct {
+	// LeaseName is the name of the Lease used as lock.
+	LeaseName string
+	// LeaseNamespace is the namespace of the Lease.
+	LeaseNamespace string
+	// Identity identifies this replica in the Lease, e.g. the pod name.
+	Identity string
+	// LeaseDuration is how long other replicas wait before taking over a Lease that is not renewed.
+	LeaseDuration time.Duration
+	// RenewDeadline is how long the leader tries to renew the Lease before giving up leadership.
+	RenewDeadline time.Duration
+	// RetryPeriod is how long replicas wait between attempts to acquire or renew the Lease.
+	RetryPeriod time.Duration
+}
+
+// Elector campaigns for the Lease and reports whether this replica is the leader.
+type Elector struct {
+	kubeClient kubernetes.Interface
+	cfg        Config
+	leading    atomic.Bool
+}
+
+// NewElector creates an elector. It does not campaign until Run is called.
+func NewElector(kubeClient kubernetes.Interface, cfg Config) *Elector {
+	return &Elector{
+		kubeClient: kubeClient,
+		cfg:        cfg,
+	}
+}
+
+// IsLeader returns whether this replica currently holds the Lease.
+func (e *Elector) IsLeader() bool {
+	return e.leading.Load()
+}
+
+// Run campaigns for the Lease until ctx is canceled. If leadership is lost, it campaigns again, so that a replica that lost the Lease,
+// e.g. due to a slow API server, can become leader again later. The Lease is released when ctx is canceled.
+func (e *Elector) Run(ctx context.Context) error {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      e.cfg.LeaseName,
+			Namespace: e.cfg.LeaseNamespace,
+		},
+		Client: e.kubeClient.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: e.cfg.Identity,
+		},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            e.cfg.LeaseName,
+		LeaseDuration:   e.cfg.LeaseDuration,
+		RenewDeadline:   e.cfg.RenewDeadline,
+		RetryPeriod:     e.cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				klog.InfoS("Started leading", "identity", e.cfg.Identity)
+				e.setLeading(true)
+			},
+			OnStoppedLeading: func() {
+				klog.InfoS("Stopped leading", "identity", e.cfg.Identity)
+				e.setLeading(false)
+			},
+			OnNewLeader: func(identity string) {
+				klog.InfoS("Observed new leader", "identity", identity)
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create leader elector: %w", err)
+	}
+
+	e.setLeading(false)
+	for {
+		elector.Run(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *Elector) setLeading(leading bool) {
+	e.leading.Store(leading)
+	if leading {
+		metrics.LeaderGauge.Set(1)
+	} else {
+		metrics.LeaderGauge.Set(0)
+	}
+}
@@ -0,0 +1,45 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func testConfig(identity string) Config {
+	return Config{
+		LeaseName:      "cluster-health-monitor",
+		LeaseNamespace: "kube-system",
+		Identity:       identity,
+		LeaseDuration:  time.Second,
+		RenewDeadline:  500 * time.Millisecond,
+		RetryPeriod:    100 * time.Millisecond,
+	}
+}
+
+func TestElector_Run(t *testing.T) {
+	g := NewWithT(t)
+	kubeClient := k8sfake.NewClientset()
+	first := NewElector(kubeClient, testConfig("first"))
+	second := NewElector(kubeClient, testConfig("second"))
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	firstDone := make(chan error)
+	go func() { firstDone <- first.Run(firstCtx) }()
+	g.Eventually(first.IsLeader, 5*time.Second, 50*time.Millisecond).Should(BeTrue())
+
+	secondCtx, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	go func() { _ = second.Run(secondCtx) }()
+	g.Consistently(second.IsLeader, time.Second, 50*time.Millisecond).Should(BeFalse())
+
+	// The first replica releases the Lease when it stops, and the second replica takes over.
+	cancelFirst()
+	g.Eventually(firstDone, 5*time.Second).Should(Receive(MatchError(context.Canceled)))
+	g.Expect(first.IsLeader()).To(BeFalse())
+	g.Eventually(second.IsLeader, 5*time.Second, 50*time.Millisecond).Should(BeTrue())
+}
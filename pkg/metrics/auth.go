@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// authCacheTTL is how long the decision for a token, verb and path is cached. Prometheus scrapes every few seconds with the same
+	// token, so caching avoids a TokenReview and a SubjectAccessReview per scrape.
+	authCacheTTL = time.Minute
+	// maxAuthCacheSize bounds the number of cached decisions. Decisions are not cached while the cache is full of unexpired decisions.
+	maxAuthCacheSize = 1024
+	// authTimeout bounds the TokenReview and SubjectAccessReview requests of a single request.
+	authTimeout = 10 * time.Second
+)
+
+// authKey identifies a cached authorization decision. The token is hashed so that it is not kept in memory.
+type authKey struct {
+	tokenHash [sha256.Size]byte
+	verb      string
+	path      string
+}
+
+// authDecision is a cached authorization decision.
+type authDecision struct {
+	// status is the HTTP status code the request is rejected with, or 0 if it is allowed.
+	status  int
+	reason  string
+	expires time.Time
+}
+
+// authFilter delegates the authentication and authorization of requests to the API server, in the same way as kubelet and
+// kube-rbac-proxy: the bearer token is authenticated with a TokenReview, and the user is authorized for the non-resource URL of the
+// request with a SubjectAccessReview. The verb is the lowercase HTTP method, so scraping /metrics requires "get" on "/metrics".
+type authFilter struct {
+	client kubernetes.Interface
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[authKey]authDecision
+}
+
+func newAuthFilter(client kubernetes.Interface) *authFilter {
+	return &authFilter{
+		client: client,
+		now:    time.Now,
+		cache:  make(map[authKey]authDecision),
+	}
+}
+
+// protect returns a handler that serves authenticated and authorized requests with next, and rejects all other requests with 401 or 403.
+// next is served for pattern. Only the decisions for requests of the pattern itself are cached, e.g. scrapes of /metrics, whereas the
+// paths below a subtree pattern are reviewed on every request, so that requests for arbitrary paths do not fill the cache.
+func (a *authFilter) protect(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cluster-health-monitor"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		status, reason, err := a.authorize(r.Context(), token, strings.ToLower(r.Method), r.URL.Path, r.URL.Path == pattern)
+		if err != nil {
+			klog.ErrorS(err, "Failed to authorize request", "path", r.URL.Path, "method", r.Method)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if status != 0 {
+			klog.V(3).InfoS("Rejected request", "path", r.URL.Path, "method", r.Method, "status", status, "reason", reason)
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="cluster-health-monitor"`)
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorize returns the HTTP status code a request with the token, verb and path is rejected with, or 0 if it is allowed, along with the
+// reason of the decision. If cache is true, decisions are cached for authCacheTTL. Errors and failed authentications are not cached, so
+// that invalid tokens do not fill the cache.
+func (a *authFilter) authorize(ctx context.Context, token, verb, path string, cache bool) (int, string, error) {
+	key := authKey{tokenHash: sha256.Sum256([]byte(token)), verb: verb, path: path}
+	now := a.now()
+	if cache {
+		a.mu.Lock()
+		decision, ok := a.cache[key]
+		a.mu.Unlock()
+		if ok && now.Before(decision.expires) {
+			return decision.status, decision.reason, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, authTimeout)
+	defer cancel()
+	decision, err := a.review(ctx, token, verb, path)
+	if err != nil {
+		return 0, "", err
+	}
+	if !cache || decision.status == http.StatusUnauthorized {
+		return decision.status, decision.reason, nil
+	}
+	decision.expires = now.Add(authCacheTTL)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for k, d := range a.cache {
+		if !now.Before(d.expires) {
+			delete(a.cache, k)
+		}
+	}
+	if len(a.cache) < maxAuthCacheSize {
+		a.cache[key] = decision
+	}
+	return decision.status, decision.reason, nil
+}
+
+// review authenticates the token with a TokenReview and authorizes its user with a SubjectAccessReview.
+func (a *authFilter) review(ctx context.Context, token, verb, path string) (authDecision, error) {
+	tokenReview, err := a.client.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authDecision{}, fmt.Errorf("failed to create token review: %w", err)
+	}
+	if !tokenReview.Status.Authenticated {
+		return authDecision{status: http.StatusUnauthorized, reason: tokenReview.Status.Error}, nil
+	}
+
+	user := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	sar, err := a.client.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:                  user.Username,
+			UID:                   user.UID,
+			Groups:                user.Groups,
+			Extra:                 extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{Path: path, Verb: verb},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return authDecision{}, fmt.Errorf("failed to create subject access review: %w", err)
+	}
+	if !sar.Status.Allowed {
+		reason := fmt.Sprintf("user %q is not allowed to %s %s", user.Username, verb, path)
+		if sar.Status.Reason != "" {
+			reason += ": " + sar.Status.Reason
+		}
+		return authDecision{status: http.StatusForbidden, reason: reason}, nil
+	}
+	return authDecision{}, nil
+}
+
+// bearerToken returns the bearer token of the Authorization header of a request.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
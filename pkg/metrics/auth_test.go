@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeAuthClient returns a client whose TokenReviews authenticate the "prometheus" token as the prometheus user, and whose
+// SubjectAccessReviews allow that user to get /metrics only.
+func newFakeAuthClient() *k8sfake.Clientset {
+	client := k8sfake.NewClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "prometheus" {
+			review.Status = authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User:          authenticationv1.UserInfo{Username: "system:serviceaccount:monitoring:prometheus"},
+			}
+		} else {
+			review.Status = authenticationv1.TokenReviewStatus{Error: "invalid token"}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		sar := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := sar.Spec.NonResourceAttributes
+		sar.Status.Allowed = sar.Spec.User == "system:serviceaccount:monitoring:prometheus" && attrs != nil &&
+			attrs.Path == "/metrics" && attrs.Verb == "get"
+		return true, sar, nil
+	})
+	return client
+}
+
+func TestAuthFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		token      string
+		wantStatus int
+	}{
+		{name: "no token", method: http.MethodGet, path: "/metrics", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", method: http.MethodGet, path: "/metrics", token: "invalid", wantStatus: http.StatusUnauthorized},
+		{name: "allowed", method: http.MethodGet, path: "/metrics", token: "prometheus", wantStatus: http.StatusOK},
+		{name: "other path", method: http.MethodGet, path: "/api/v1/status", token: "prometheus", wantStatus: http.StatusForbidden},
+		{name: "other verb", method: http.MethodPost, path: "/metrics", token: "prometheus", wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			handler := newAuthFilter(newFakeAuthClient()).protect(tt.path, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			g.Expect(rec.Code).To(Equal(tt.wantStatus))
+		})
+	}
+}
+
+func TestAuthFilter_Cache(t *testing.T) {
+	g := NewWithT(t)
+	client := newFakeAuthClient()
+	filter := newAuthFilter(client)
+	now := time.Now()
+	filter.now = func() time.Time { return now }
+	handler := filter.protect("/metrics", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	subtreeHandler := filter.protect("/admin/", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	request := func(handler http.Handler, path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	scrape := func() int {
+		return request(handler, "/metrics", "prometheus")
+	}
+
+	g.Expect(scrape()).To(Equal(http.StatusOK))
+	g.Expect(scrape()).To(Equal(http.StatusOK))
+	g.Expect(client.Actions()).To(HaveLen(2), "the second scrape is served from the cache")
+
+	now = now.Add(authCacheTTL)
+	g.Expect(scrape()).To(Equal(http.StatusOK))
+	g.Expect(client.Actions()).To(HaveLen(4), "expired decisions are reviewed again")
+
+	g.Expect(request(handler, "/metrics", "invalid")).To(Equal(http.StatusUnauthorized))
+	g.Expect(request(handler, "/metrics", "invalid")).To(Equal(http.StatusUnauthorized))
+	g.Expect(client.Actions()).To(HaveLen(6), "failed authentications are not cached")
+
+	g.Expect(request(subtreeHandler, "/admin/checkers", "prometheus")).To(Equal(http.StatusForbidden))
+	g.Expect(request(subtreeHandler, "/admin/checkers", "prometheus")).To(Equal(http.StatusForbidden))
+	g.Expect(client.Actions()).To(HaveLen(10), "paths below a subtree pattern are not cached")
+	g.Expect(filter.cache).To(HaveLen(1))
+}
+
+func TestAuthFilter_CacheSize(t *testing.T) {
+	g := NewWithT(t)
+	filter := newAuthFilter(newFakeAuthClient())
+	now := time.Now()
+	filter.now = func() time.Time { return now }
+	for i := range maxAuthCacheSize + 1 {
+		status, _, err := filter.authorize(context.Background(), "prometheus", "get", fmt.Sprintf("/path-%d", i), true)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(status).To(Equal(http.StatusForbidden))
+	}
+	g.Expect(filter.cache).To(HaveLen(maxAuthCacheSize), "decisions are not cached while the cache is full")
+
+	now = now.Add(authCacheTTL)
+	_, _, err := filter.authorize(context.Background(), "prometheus", "get", "/metrics", true)
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(filter.cache).To(HaveLen(1), "expired decisions make room for new ones")
+}
+
+func TestServer_HealthEndpointsAreNotAuthenticated(t *testing.T) {
+	g := NewWithT(t)
+	s, err := NewServer(Options{AuthClient: newFakeAuthClient()})
+	g.Expect(err).NotTo(HaveOccurred())
+	s.Handle("/api/v1/status", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+
+	for path, want := range map[string]int{
+		"/healthz":       http.StatusOK,
+		"/readyz":        http.StatusOK,
+		"/metrics":       http.StatusUnauthorized,
+		"/api/v1/status": http.StatusUnauthorized,
+	} {
+		rec := httptest.NewRecorder()
+		s.mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		g.Expect(rec.Code).To(Equal(want), path)
+	}
+}
@@ -6,13 +6,47 @@ const (
 	HealthyStatus   = "Healthy"
 	UnhealthyStatus = "Unhealthy"
 	UnknownStatus   = "Unknown"
+	SkippedStatus   = "Skipped"
 
 	// error_code is required although healthy and unknown checkers do not use it.
 	// We set a default value for healthy and unknown result.
 	HealthyCode = HealthyStatus
 	UnknownCode = UnknownStatus
+	// PanicCode is the error code of the unknown result recorded for a checker run that panicked.
+	PanicCode = "Panic"
+
+	// Reasons of missed checker runs.
+	MissedRunOverlap          = "Overlap"
+	MissedRunQueueFull        = "QueueFull"
+	MissedRunConcurrencyLimit = "ConcurrencyLimit"
+	MissedRunPanicBackoff     = "PanicBackoff"
+
+	// Reasons of failed checker runs.
+	RunFailureTimeout = "Timeout"
+	RunFailureError   = "Error"
+	RunFailurePanic   = "Panic"
+
+	ReloadSuccess = "Success"
+	ReloadFailure = "Failure"
+
+	// Results of webhook notifications.
+	WebhookSent    = "Sent"
+	WebhookFailed  = "Failed"
+	WebhookDropped = "Dropped"
+
+	// Results of the objects handled by the reaper.
+	ReaperFound   = "Found"
+	ReaperDeleted = "Deleted"
+	ReaperFailed  = "Failed"
 )
 
+// durationBuckets are the histogram buckets in seconds used for checker latencies. They range from 5ms for fast DNS queries to about 40s
+// for pod startup runs which may include node provisioning.
+var durationBuckets = prometheus.ExponentialBuckets(0.005, 2, 14)
+
+// The checker, webhook and reaper metrics are labeled by the cluster they are about, i.e. the kubeconfig context of the cluster when the
+// monitor checks several clusters. The cluster label is empty when the monitor checks a single cluster. The leader and config reload
+// metrics are about the monitor itself and are not labeled by cluster.
 var (
 	// CheckerResultCounter is a Prometheus counter that tracks the results of checker runs.
 	CheckerResultCounter = prometheus.NewCounterVec(
@@ -20,15 +54,201 @@ var (
 			Name: "cluster_health_monitor_checker_result_total",
 			Help: "Total number of checker runs, labeled by status and code",
 		},
-		[]string{"checker_type", "checker_name", "status", "error_code"},
+		[]string{"cluster", "checker_type", "checker_name", "status", "error_code"},
+	)
+
+	// CheckerTargetResultCounter is a Prometheus counter that tracks the results of checkers that record one result per target, e.g. per
+	// CoreDNS pod.
+	CheckerTargetResultCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_health_monitor_checker_target_result_total",
+			Help: "Total number of checker target results, labeled by target, status and code",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "target", "status", "error_code"},
+	)
+
+	// CheckerDroppedTargetResultCounter is a Prometheus counter that tracks target results that were dropped because the checker reached
+	// its maximum number of targets.
+	CheckerDroppedTargetResultCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_health_monitor_checker_dropped_target_results_total",
+			Help: "Total number of checker target results dropped because the checker reached its maximum number of targets",
+		},
+		[]string{"cluster", "checker_type", "checker_name"},
+	)
+
+	// CheckerRunDuration is a Prometheus histogram that tracks the duration of scheduled checker runs.
+	CheckerRunDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cluster_health_monitor_checker_run_duration_seconds",
+			Help:    "Duration of checker runs in seconds, labeled by status",
+			Buckets: durationBuckets,
+		},
+		[]string{"cluster", "checker_type", "checker_name", "status"},
+	)
+
+	// CheckerStepDuration is a Prometheus histogram that tracks the duration of individual steps within a checker run.
+	CheckerStepDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "cluster_health_monitor_checker_step_duration_seconds",
+			Help:    "Duration of individual checker steps in seconds, labeled by step",
+			Buckets: durationBuckets,
+		},
+		[]string{"cluster", "checker_type", "checker_name", "step"},
+	)
+
+	// CheckerMissedRunCounter is a Prometheus counter that tracks scheduled runs that did not start because previous runs of the checker
+	// were still in progress, or because the checker is backed off after a panic.
+	CheckerMissedRunCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_health_monitor_checker_missed_runs_total",
+			Help: "Total number of scheduled checker runs that did not start because previous runs were still in progress or the checker is backed off, labeled by reason",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "reason"},
+	)
+
+	// CheckerRunFailureCounter is a Prometheus counter that tracks checker runs that exceeded the scheduler timeout, separately from runs
+	// in which the checker returned an error or panicked.
+	CheckerRunFailureCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_health_monitor_checker_run_failures_total",
+			Help: "Total number of failed checker runs, labeled by whether the run exceeded its timeout, the checker returned an error or the checker panicked",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "reason"},
+	)
+
+	// CheckerSkippedGauge is a Prometheus gauge that is set to 1 for each configured checker that was skipped when it was built because it
+	// does not apply to the cluster.
+	CheckerSkippedGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_skipped",
+			Help: "Whether a configured checker was skipped at build time because it does not apply to the cluster",
+		},
+		[]string{"cluster", "checker_type", "checker_name"},
+	)
+
+	// CheckerStatusGauge is a Prometheus gauge that is set to 1 for the status of the latest result of each checker and to 0 for the other
+	// statuses.
+	CheckerStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_status",
+			Help: "Status of the latest checker result, 1 for the current status",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "status"},
+	)
+
+	// CheckerTargetStatusGauge is a Prometheus gauge that is set to 1 for the status of the latest result of each target of a checker and
+	// to 0 for the other statuses.
+	CheckerTargetStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_target_status",
+			Help: "Status of the latest checker result for a target, 1 for the current status",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "target", "status"},
+	)
+
+	// CheckerLastRunTimestamp is a Prometheus gauge that holds the time at which each checker last recorded a result, including target
+	// results.
+	CheckerLastRunTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_last_run_timestamp_seconds",
+			Help: "Unix time at which the checker last recorded a result",
+		},
+		[]string{"cluster", "checker_type", "checker_name"},
+	)
+
+	// CheckerLastSuccessTimestamp is a Prometheus gauge that holds the time at which each checker last recorded a healthy result.
+	CheckerLastSuccessTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_last_success_timestamp_seconds",
+			Help: "Unix time at which the checker last recorded a healthy result",
+		},
+		[]string{"cluster", "checker_type", "checker_name"},
+	)
+
+	// CheckerTargetLastSuccessTimestamp is a Prometheus gauge that holds the time at which each target of a checker last recorded a
+	// healthy result.
+	CheckerTargetLastSuccessTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_target_last_success_timestamp_seconds",
+			Help: "Unix time at which the checker last recorded a healthy result for a target",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "target"},
+	)
+
+	// CheckerEffectiveStatusGauge is a Prometheus gauge that is set to 1 for the effective status of each checker and to 0 for the other
+	// statuses. The effective status only changes after the configured number of consecutive results.
+	CheckerEffectiveStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_effective_status",
+			Help: "Effective status of a checker after applying the failure and success thresholds, 1 for the current status",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "status"},
+	)
+
+	// CheckerTargetEffectiveStatusGauge is a Prometheus gauge that is set to 1 for the effective status of each target of a checker and to
+	// 0 for the other statuses.
+	CheckerTargetEffectiveStatusGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_target_effective_status",
+			Help: "Effective status of a checker target after applying the failure and success thresholds, 1 for the current status",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "target", "status"},
+	)
+
+	// CheckerFlappingGauge is a Prometheus gauge that is set to 1 while a checker with flap detection is flapping and 0 otherwise.
+	CheckerFlappingGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_flapping",
+			Help: "Whether the result status of a checker changed more often than allowed within the flap detection window",
+		},
+		[]string{"cluster", "checker_type", "checker_name"},
+	)
+
+	// CheckerTargetFlappingGauge is a Prometheus gauge that is set to 1 while a target of a checker with flap detection is flapping and 0
+	// otherwise.
+	CheckerTargetFlappingGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_checker_target_flapping",
+			Help: "Whether the result status of a checker target changed more often than allowed within the flap detection window",
+		},
+		[]string{"cluster", "checker_type", "checker_name", "target"},
+	)
+
+	// LeaderGauge is a Prometheus gauge that is set to 1 while the replica holds the leader election lease and 0 otherwise. It is always 1
+	// when leader election is disabled.
+	LeaderGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "cluster_health_monitor_leader",
+			Help: "Whether this replica is the leader and runs the checkers that create resources",
+		},
+	)
+
+	// ConfigReloadCounter is a Prometheus counter that tracks the results of configuration reloads.
+	ConfigReloadCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_health_monitor_config_reload_total",
+			Help: "Total number of configuration reloads, labeled by result",
+		},
+		[]string{"result"},
+	)
+
+	// WebhookNotificationCounter is a Prometheus counter that tracks the notifications of checker status changes sent to webhooks.
+	WebhookNotificationCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cluster_health_monitor_webhook_notifications_total",
+			Help: "Total number of checker status change notifications sent to webhooks, labeled by webhook and result",
+		},
+		[]string{"cluster", "webhook", "result"},
 	)
 
-	// CoreDNSPodResultCounter is a Prometheus counter that tracks the results of CoreDNS pod checker runs.
-	CoreDNSPodResultCounter = prometheus.NewCounterVec(
+	// ReaperObjectCounter is a Prometheus counter that tracks the objects left behind by checkers that were renamed or removed, labeled by
+	// the kind of the object and whether it was found, deleted or failed to be deleted.
+	ReaperObjectCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "cluster_health_monitor_coredns_pod_result_total",
-			Help: "Total number of CoreDNS pod checker runs, labeled by status and code",
+			Name: "cluster_health_monitor_reaper_objects_total",
+			Help: "Total number of orphaned checker objects found, deleted or failed to be deleted by the reaper, labeled by kind and result",
 		},
-		[]string{"checker_type", "checker_name", "pod_name", "status", "error_code"},
+		[]string{"cluster", "kind", "result"},
 	)
 )
@@ -2,53 +2,265 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
+const (
+	// DefaultBindAddress is the default address the metrics server listens on.
+	DefaultBindAddress = "0.0.0.0"
+	// DefaultPort is the default port the metrics server listens on.
+	DefaultPort = 9800
+)
+
+// HealthCheck reports whether some part of the process is healthy. It returns a non-nil error describing the problem if it is not.
+type HealthCheck func() error
+
+// Options configures the address of the metrics server and how it is secured.
+type Options struct {
+	// BindAddress is the address the server listens on. Defaults to DefaultBindAddress.
+	BindAddress string
+	// Port is the port the server listens on. Defaults to DefaultPort.
+	Port int
+	// TLSCertFile and TLSKeyFile are the paths of a PEM-encoded certificate and private key. If they are set, the server serves HTTPS and
+	// reloads the certificate when the files change. Either both or neither must be set.
+	TLSCertFile string
+	TLSKeyFile  string
+	// AuthClient, if set, is used to authenticate requests with TokenReview and to authorize them with SubjectAccessReview. All endpoints
+	// except /healthz and /readyz then require a bearer token whose user is allowed the lowercase HTTP method as verb on the path, e.g.
+	// "get" on "/metrics". The health endpoints stay unauthenticated for kubelet probes.
+	AuthClient kubernetes.Interface
+}
+
 // Server holds Prometheus collectors and exposes them via HTTP.
 type Server struct {
 	registry *prometheus.Registry
-	port     int
+	addr     string
 	server   *http.Server
+	mux      *http.ServeMux
+	certs    *certWatcher
+	auth     *authFilter
+
+	mu           sync.RWMutex
+	healthChecks map[string]HealthCheck
+	readyChecks  map[string]HealthCheck
 }
 
-// NewServer creates a new Metrics instance with a custom registry and listen address.
-func NewServer(port int) (*Server, error) {
+// NewServer creates a new Metrics instance with a custom registry, listening on the address of the options.
+func NewServer(opts Options) (*Server, error) {
+	if (opts.TLSCertFile == "") != (opts.TLSKeyFile == "") {
+		return nil, errors.New("both the TLS certificate file and the TLS key file must be set to serve HTTPS")
+	}
+	if opts.BindAddress == "" {
+		opts.BindAddress = DefaultBindAddress
+	}
+	if opts.Port == 0 {
+		opts.Port = DefaultPort
+	}
 	reg := prometheus.NewRegistry()
 	if err := reg.Register(CheckerResultCounter); err != nil {
 		klog.ErrorS(err, "Failed to register checker result counter")
 		return nil, err
 	}
-	if err := reg.Register(CoreDNSPodResultCounter); err != nil {
-		klog.ErrorS(err, "Failed to register CoreDNS pod result counter")
+	if err := reg.Register(CheckerTargetResultCounter); err != nil {
+		klog.ErrorS(err, "Failed to register checker target result counter")
+		return nil, err
+	}
+	if err := reg.Register(CheckerDroppedTargetResultCounter); err != nil {
+		klog.ErrorS(err, "Failed to register checker dropped target result counter")
+		return nil, err
+	}
+	if err := reg.Register(CheckerRunDuration); err != nil {
+		klog.ErrorS(err, "Failed to register checker run duration histogram")
+		return nil, err
+	}
+	if err := reg.Register(CheckerStepDuration); err != nil {
+		klog.ErrorS(err, "Failed to register checker step duration histogram")
+		return nil, err
+	}
+	if err := reg.Register(CheckerMissedRunCounter); err != nil {
+		klog.ErrorS(err, "Failed to register checker missed run counter")
 		return nil, err
 	}
-	return &Server{
-		registry: reg,
-		port:     port,
-	}, nil
+	if err := reg.Register(CheckerRunFailureCounter); err != nil {
+		klog.ErrorS(err, "Failed to register checker run failure counter")
+		return nil, err
+	}
+	if err := reg.Register(CheckerSkippedGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker skipped gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerStatusGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker status gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerTargetStatusGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker target status gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerLastRunTimestamp); err != nil {
+		klog.ErrorS(err, "Failed to register checker last run timestamp gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerLastSuccessTimestamp); err != nil {
+		klog.ErrorS(err, "Failed to register checker last success timestamp gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerTargetLastSuccessTimestamp); err != nil {
+		klog.ErrorS(err, "Failed to register checker target last success timestamp gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerEffectiveStatusGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker effective status gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerTargetEffectiveStatusGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker target effective status gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerFlappingGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker flapping gauge")
+		return nil, err
+	}
+	if err := reg.Register(CheckerTargetFlappingGauge); err != nil {
+		klog.ErrorS(err, "Failed to register checker target flapping gauge")
+		return nil, err
+	}
+	if err := reg.Register(LeaderGauge); err != nil {
+		klog.ErrorS(err, "Failed to register leader gauge")
+		return nil, err
+	}
+	if err := reg.Register(ConfigReloadCounter); err != nil {
+		klog.ErrorS(err, "Failed to register config reload counter")
+		return nil, err
+	}
+	if err := reg.Register(WebhookNotificationCounter); err != nil {
+		klog.ErrorS(err, "Failed to register webhook notification counter")
+		return nil, err
+	}
+	if err := reg.Register(ReaperObjectCounter); err != nil {
+		klog.ErrorS(err, "Failed to register reaper object counter")
+		return nil, err
+	}
+	s := &Server{
+		registry:     reg,
+		addr:         net.JoinHostPort(opts.BindAddress, strconv.Itoa(opts.Port)),
+		mux:          http.NewServeMux(),
+		healthChecks: make(map[string]HealthCheck),
+		readyChecks:  make(map[string]HealthCheck),
+	}
+	if opts.TLSCertFile != "" {
+		certs, err := newCertWatcher(opts.TLSCertFile, opts.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+	}
+	if opts.AuthClient != nil {
+		s.auth = newAuthFilter(opts.AuthClient)
+	}
+	// OpenMetrics is required to expose the trace ID exemplars of the result and duration metrics.
+	s.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	s.mux.Handle("/healthz", s.checksHandler(s.healthChecks))
+	s.mux.Handle("/readyz", s.checksHandler(s.readyChecks))
+	return s, nil
 }
 
-// Run starts the HTTP server to expose Prometheus metrics.
+// Handle registers an additional handler for the given pattern on the server. It must be called before Run. If the server authenticates
+// requests, so does the handler.
+func (m *Server) Handle(pattern string, handler http.Handler) {
+	if m.auth != nil {
+		handler = m.auth.protect(pattern, handler)
+	}
+	m.mux.Handle(pattern, handler)
+}
+
+// AddHealthCheck adds a named check to the /healthz endpoint, which reports whether the process is alive.
+func (m *Server) AddHealthCheck(name string, check HealthCheck) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.healthChecks[name] = check
+}
+
+// AddReadyCheck adds a named check to the /readyz endpoint, which reports whether the process is ready to run checkers.
+func (m *Server) AddReadyCheck(name string, check HealthCheck) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readyChecks[name] = check
+}
+
+// checksHandler returns a handler that runs all the given checks. It responds with 200 if all checks pass and 500 otherwise. The
+// response body lists the result of each check.
+func (m *Server) checksHandler(checks map[string]HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		m.mu.RLock()
+		names := make([]string, 0, len(checks))
+		for name := range checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var b strings.Builder
+		failed := false
+		for _, name := range names {
+			if err := checks[name](); err != nil {
+				failed = true
+				fmt.Fprintf(&b, "[-]%s failed: %v\n", name, err)
+				continue
+			}
+			fmt.Fprintf(&b, "[+]%s ok\n", name)
+		}
+		m.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if failed {
+			w.WriteHeader(http.StatusInternalServerError)
+			b.WriteString("check failed\n")
+		} else {
+			b.WriteString("ok\n")
+		}
+		if _, err := w.Write([]byte(b.String())); err != nil {
+			klog.ErrorS(err, "Failed to write health check response")
+		}
+	})
+}
+
+// Run starts the HTTP server to expose Prometheus metrics. It serves HTTPS if a TLS certificate is configured.
 func (m *Server) Run(ctx context.Context) error {
-	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
-	addr := fmt.Sprintf("0.0.0.0:%d", m.port)
 	m.server = &http.Server{
-		Addr:    addr,
-		Handler: mux,
+		Addr:    m.addr,
+		Handler: m.mux,
 	}
 	errCh := make(chan error, 1)
-	go func() {
-		errCh <- m.server.ListenAndServe()
-	}()
+	if m.certs != nil {
+		m.server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: m.certs.getCertificate,
+		}
+		go m.certs.run(ctx)
+		go func() {
+			errCh <- m.server.ListenAndServeTLS("", "")
+		}()
+	} else {
+		go func() {
+			errCh <- m.server.ListenAndServe()
+		}()
+	}
 	klog.InfoS("Started Prometheus metrics server",
-		"address", addr)
+		"address", m.addr,
+		"tls", m.certs != nil,
+		"auth", m.auth != nil)
 	select {
 	case <-ctx.Done():
 		// Context canceled, initiate graceful shutdown.
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// certReloadInterval is how often the certificate and key files are checked for changes.
+const certReloadInterval = 10 * time.Second
+
+// certWatcher serves a TLS certificate from a certificate and a key file and reloads it when the files change, e.g. when cert-manager
+// renews the certificate. Like the configuration file, the files are polled because Kubernetes updates mounted Secrets by swapping
+// symlinks.
+type certWatcher struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	certData []byte
+	keyData  []byte
+}
+
+// newCertWatcher creates a certWatcher and loads the certificate. It fails if the files cannot be read or do not hold a valid certificate
+// and key pair.
+func newCertWatcher(certFile, keyFile string) (*certWatcher, error) {
+	w := &certWatcher{certFile: certFile, keyFile: keyFile}
+	if _, err := w.poll(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// getCertificate returns the current certificate. It is used as tls.Config.GetCertificate so that new connections use the reloaded
+// certificate without restarting the server.
+func (w *certWatcher) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cert, nil
+}
+
+// run polls the certificate and key files until ctx is done. If the files change but do not hold a valid pair, e.g. while only one of
+// them is updated, the previous certificate is kept.
+func (w *certWatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(certReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			reloaded, err := w.poll()
+			if err != nil {
+				klog.ErrorS(err, "Failed to reload TLS certificate, keeping the previous certificate", "certFile", w.certFile,
+					"keyFile", w.keyFile)
+				continue
+			}
+			if reloaded {
+				klog.InfoS("Reloaded TLS certificate", "certFile", w.certFile, "keyFile", w.keyFile)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// poll reads the certificate and key files and loads them if their content has changed. It returns whether a new certificate was loaded.
+func (w *certWatcher) poll() (bool, error) {
+	certData, err := os.ReadFile(w.certFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read TLS certificate file %q: %w", w.certFile, err)
+	}
+	keyData, err := os.ReadFile(w.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read TLS key file %q: %w", w.keyFile, err)
+	}
+	w.mu.RLock()
+	unchanged := bytes.Equal(certData, w.certData) && bytes.Equal(keyData, w.keyData)
+	w.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	cert, err := tls.X509KeyPair(certData, keyData)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cert = &cert
+	w.certData = certData
+	w.keyData = keyData
+	return true, nil
+}
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+// writeCertKey writes a new self-signed certificate and key for host to the files.
+func writeCertKey(g *WithT, certFile, keyFile, host string) {
+	certData, keyData, err := certutil.GenerateSelfSignedCertKey(host, nil, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(os.WriteFile(certFile, certData, 0o600)).To(Succeed())
+	g.Expect(os.WriteFile(keyFile, keyData, 0o600)).To(Succeed())
+}
+
+func TestCertWatcher_Reload(t *testing.T) {
+	g := NewWithT(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertKey(g, certFile, keyFile, "first")
+
+	w, err := newCertWatcher(certFile, keyFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	first, err := w.getCertificate(nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(first.Leaf.Subject.CommonName).To(HavePrefix("first"))
+
+	reloaded, err := w.poll()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reloaded).To(BeFalse(), "unchanged files are not reloaded")
+
+	g.Expect(os.WriteFile(keyFile, []byte("not a key"), 0o600)).To(Succeed())
+	_, err = w.poll()
+	g.Expect(err).To(HaveOccurred())
+	current, _ := w.getCertificate(nil)
+	g.Expect(current).To(BeIdenticalTo(first), "an invalid pair keeps the previous certificate")
+
+	writeCertKey(g, certFile, keyFile, "second")
+	reloaded, err = w.poll()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(reloaded).To(BeTrue())
+	current, _ = w.getCertificate(nil)
+	g.Expect(current.Leaf.Subject.CommonName).To(HavePrefix("second"))
+}
+
+func TestNewServer_RequiresCertAndKey(t *testing.T) {
+	g := NewWithT(t)
+	_, err := NewServer(Options{TLSCertFile: "tls.crt"})
+	g.Expect(err).To(HaveOccurred())
+	_, err = NewServer(Options{TLSCertFile: filepath.Join(t.TempDir(), "missing.crt"), TLSKeyFile: "missing.key"})
+	g.Expect(err).To(HaveOccurred())
+}
@@ -0,0 +1,289 @@
+// Package reaper deletes the objects left behind by checkers that were renamed or removed from the configuration. Checkers only garbage
+// collect the objects labeled with their current name, so the objects of a checker that no longer exists are never deleted otherwise.
+package reaper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// These are the kinds of the objects created by checkers, as recorded in the kind label of the reaper metrics.
+const (
+	KindPod                   = "Pod"
+	KindPersistentVolumeClaim = "PersistentVolumeClaim"
+	KindStorageClass          = "StorageClass"
+	KindNodePool              = "NodePool"
+	KindConfigMap             = "ConfigMap"
+)
+
+// nodePoolGVR is the resource of Karpenter NodePools, which are created by the PodStartup checker when the node provisioning test is
+// enabled.
+var nodePoolGVR = schema.GroupVersionResource{Group: "karpenter.sh", Version: "v1", Resource: "nodepools"}
+
+// Config is the configuration of the reaper.
+type Config struct {
+	// Cluster is the name of the cluster the reaper deletes objects in. It labels the metrics of the reaper and is empty unless the
+	// monitor checks several clusters.
+	Cluster string
+	// Interval is how often the reaper looks for orphaned objects.
+	Interval time.Duration
+	// MinAge is the minimum age of the orphaned objects that are deleted, so that the objects of a checker are not deleted while it is
+	// being replaced, e.g. on a configuration reload.
+	MinAge time.Duration
+	// LabelKeys are the label keys of the objects created by checkers, in addition to the label keys of the active checkers. They should
+	// include the label keys of the checkers that may have been removed before the monitor started.
+	LabelKeys []string
+}
+
+// Reaper periodically deletes the objects that carry the label key of a checker but are not owned by any active checker. An object is
+// considered if its name also follows the naming scheme of the checker objects of its kind, as a safety measure against deleting
+// objects that happen to carry a label key in use by the monitor.
+type Reaper struct {
+	kubeClient    kubernetes.Interface
+	dynamicClient dynamic.Interface
+	cfg           Config
+	// checkers returns the active checkers.
+	checkers func() []checker.Checker
+	// isLeader reports whether this replica deletes orphaned objects. All replicas do if it is nil.
+	isLeader func() bool
+	now      func() time.Time
+
+	// labelKeys holds every label key the reaper looks for, including the label keys of the checkers that were active in previous
+	// passes. Reap must not be called concurrently.
+	labelKeys map[string]struct{}
+	kinds     []kind
+}
+
+// kind lists and deletes the objects of one kind created by checkers.
+type kind struct {
+	name string
+	// matchesName returns whether an object name follows the naming scheme of the checker objects of the kind.
+	matchesName func(name string) bool
+	// list returns the objects of the kind in all namespaces that match the label selector.
+	list func(ctx context.Context, selector string) ([]metav1.Object, error)
+	// delete deletes an object of the kind.
+	delete func(ctx context.Context, obj metav1.Object, opts metav1.DeleteOptions) error
+}
+
+// New creates a reaper that deletes the objects that are not owned by any of the checkers returned by checkers.
+func New(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, checkers func() []checker.Checker, cfg Config) *Reaper {
+	r := &Reaper{
+		kubeClient:    kubeClient,
+		dynamicClient: dynamicClient,
+		cfg:           cfg,
+		checkers:      checkers,
+		now:           time.Now,
+		labelKeys:     make(map[string]struct{}),
+	}
+	for _, key := range cfg.LabelKeys {
+		r.labelKeys[key] = struct{}{}
+	}
+	r.kinds = []kind{
+		{
+			name:        KindPod,
+			matchesName: func(name string) bool { return strings.Contains(name, "-synthetic-") },
+			list: func(ctx context.Context, selector string) ([]metav1.Object, error) {
+				list, err := r.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					return nil, err
+				}
+				objs := make([]metav1.Object, 0, len(list.Items))
+				for i := range list.Items {
+					objs = append(objs, &list.Items[i])
+				}
+				return objs, nil
+			},
+			delete: func(ctx context.Context, obj metav1.Object, opts metav1.DeleteOptions) error {
+				return r.kubeClient.CoreV1().Pods(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+			},
+		},
+		{
+			name:        KindPersistentVolumeClaim,
+			matchesName: func(name string) bool { return strings.HasPrefix(name, "clusterhealthmonitor-") },
+			list: func(ctx context.Context, selector string) ([]metav1.Object, error) {
+				list, err := r.kubeClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					return nil, err
+				}
+				objs := make([]metav1.Object, 0, len(list.Items))
+				for i := range list.Items {
+					objs = append(objs, &list.Items[i])
+				}
+				return objs, nil
+			},
+			delete: func(ctx context.Context, obj metav1.Object, opts metav1.DeleteOptions) error {
+				return r.kubeClient.CoreV1().PersistentVolumeClaims(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+			},
+		},
+		{
+			name: KindStorageClass,
+			// Only the Azure File storage classes are created by the PodStartup checker, the other CSI tests use builtin storage classes.
+			matchesName: func(name string) bool { return strings.HasPrefix(name, "clusterhealthmonitor-azurefile-sc-") },
+			list: func(ctx context.Context, selector string) ([]metav1.Object, error) {
+				list, err := r.kubeClient.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					return nil, err
+				}
+				objs := make([]metav1.Object, 0, len(list.Items))
+				for i := range list.Items {
+					objs = append(objs, &list.Items[i])
+				}
+				return objs, nil
+			},
+			delete: func(ctx context.Context, obj metav1.Object, opts metav1.DeleteOptions) error {
+				return r.kubeClient.StorageV1().StorageClasses().Delete(ctx, obj.GetName(), opts)
+			},
+		},
+		{
+			name:        KindNodePool,
+			matchesName: func(name string) bool { return strings.Contains(name, "-nodepool-") },
+			list: func(ctx context.Context, selector string) ([]metav1.Object, error) {
+				list, err := r.dynamicClient.Resource(nodePoolGVR).List(ctx, metav1.ListOptions{LabelSelector: selector})
+				if apierrors.IsNotFound(err) {
+					// Karpenter is not installed, so there are no NodePools.
+					return nil, nil
+				}
+				if err != nil {
+					return nil, err
+				}
+				objs := make([]metav1.Object, 0, len(list.Items))
+				for i := range list.Items {
+					objs = append(objs, &list.Items[i])
+				}
+				return objs, nil
+			},
+			delete: func(ctx context.Context, obj metav1.Object, opts metav1.DeleteOptions) error {
+				return r.dynamicClient.Resource(nodePoolGVR).Delete(ctx, obj.GetName(), opts)
+			},
+		},
+		{
+			name:        KindConfigMap,
+			matchesName: func(name string) bool { return strings.Contains(name, "-empty-configmap-") },
+			list: func(ctx context.Context, selector string) ([]metav1.Object, error) {
+				list, err := r.kubeClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+				if err != nil {
+					return nil, err
+				}
+				objs := make([]metav1.Object, 0, len(list.Items))
+				for i := range list.Items {
+					objs = append(objs, &list.Items[i])
+				}
+				return objs, nil
+			},
+			delete: func(ctx context.Context, obj metav1.Object, opts metav1.DeleteOptions) error {
+				return r.kubeClient.CoreV1().ConfigMaps(obj.GetNamespace()).Delete(ctx, obj.GetName(), opts)
+			},
+		},
+	}
+	return r
+}
+
+// SetIsLeader makes the reaper only delete orphaned objects while isLeader returns true, like the checkers that create them.
+func (r *Reaper) SetIsLeader(isLeader func() bool) {
+	r.isLeader = isLeader
+}
+
+// Run deletes orphaned objects every interval until ctx is canceled.
+func (r *Reaper) Run(ctx context.Context) error {
+	klog.InfoS("Started reaper", "cluster", r.cfg.Cluster, "interval", r.cfg.Interval, "minAge", r.cfg.MinAge)
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := r.Reap(ctx); err != nil {
+				klog.ErrorS(err, "Failed to reap orphaned checker objects", "cluster", r.cfg.Cluster)
+			}
+		}
+	}
+}
+
+// Reap deletes the objects that carry a monitor label key, are not owned by any active checker and are older than the minimum age. It
+// is a no-op while this replica is not the leader.
+func (r *Reaper) Reap(ctx context.Context) error {
+	if r.isLeader != nil && !r.isLeader() {
+		return nil
+	}
+	var owners []checker.ResourceOwner
+	for _, chk := range r.checkers() {
+		if owner, ok := chk.(checker.ResourceOwner); ok {
+			owners = append(owners, owner)
+			r.labelKeys[owner.ResourceLabelKey()] = struct{}{}
+		}
+	}
+
+	var errs []error
+	for _, k := range r.kinds {
+		if err := r.reapKind(ctx, k, owners); err != nil {
+			errs = append(errs, fmt.Errorf("failed to reap %s objects: %w", k.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reapKind deletes the orphaned objects of a kind.
+func (r *Reaper) reapKind(ctx context.Context, k kind, owners []checker.ResourceOwner) error {
+	// An object may carry several label keys, so it is only handled once.
+	seen := make(map[string]struct{})
+	var errs []error
+	for key := range r.labelKeys {
+		objs, err := k.list(ctx, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list objects with label key %q: %w", key, err))
+			continue
+		}
+		for _, obj := range objs {
+			if _, ok := seen[objectKey(obj)]; ok {
+				continue
+			}
+			seen[objectKey(obj)] = struct{}{}
+			if !k.matchesName(obj.GetName()) || isOwned(obj, owners) || r.now().Sub(obj.GetCreationTimestamp().Time) < r.cfg.MinAge {
+				continue
+			}
+			metrics.ReaperObjectCounter.WithLabelValues(r.cfg.Cluster, k.name, metrics.ReaperFound).Inc()
+			// The UID precondition makes sure that an object that was recreated with the same name in the meantime is not deleted.
+			err := k.delete(ctx, obj, metav1.DeleteOptions{Preconditions: metav1.NewUIDPreconditions(string(obj.GetUID()))})
+			if err != nil && !apierrors.IsNotFound(err) {
+				metrics.ReaperObjectCounter.WithLabelValues(r.cfg.Cluster, k.name, metrics.ReaperFailed).Inc()
+				errs = append(errs, fmt.Errorf("failed to delete %s %s: %w", k.name, objectKey(obj), err))
+				continue
+			}
+			metrics.ReaperObjectCounter.WithLabelValues(r.cfg.Cluster, k.name, metrics.ReaperDeleted).Inc()
+			klog.InfoS("Deleted orphaned checker object", "cluster", r.cfg.Cluster, "kind", k.name, "object", objectKey(obj), "labelKey", key,
+				"labelValue", obj.GetLabels()[key])
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isOwned returns whether an object is owned by one of the active checkers whose label key it carries.
+func isOwned(obj metav1.Object, owners []checker.ResourceOwner) bool {
+	for _, owner := range owners {
+		if _, ok := obj.GetLabels()[owner.ResourceLabelKey()]; ok && owner.OwnsResource(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+// objectKey returns the namespace/name key of a namespaced object, or the name of a cluster-scoped object.
+func objectKey(obj metav1.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,190 @@
+package reaper
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	podLabelKey       = "kubernetes.azure.com/cluster-health-monitor-checker-synthetic"
+	configMapLabelKey = "kubernetes.azure.com/cluster-health-monitor-checker-apiserver"
+)
+
+// fakeOwner owns the objects labeled with its name, and the objects whose name starts with namePrefix if it is set.
+type fakeOwner struct {
+	name       string
+	labelKey   string
+	namePrefix string
+}
+
+func (f *fakeOwner) Name() string             { return f.name }
+func (f *fakeOwner) Type() config.CheckerType { return config.CheckerType("fake") }
+func (f *fakeOwner) Run(context.Context)      {}
+func (f *fakeOwner) ResourceLabelKey() string { return f.labelKey }
+func (f *fakeOwner) OwnsResource(obj metav1.Object) bool {
+	return obj.GetLabels()[f.labelKey] == f.name || f.namePrefix != "" && strings.HasPrefix(obj.GetName(), f.namePrefix)
+}
+
+func objectMeta(name, namespace string, labels map[string]string, age time.Duration) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:              name,
+		Namespace:         namespace,
+		Labels:            labels,
+		CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+	}
+}
+
+func nodePool(name string, labels map[string]string, age time.Duration) *unstructured.Unstructured {
+	np := &unstructured.Unstructured{}
+	np.SetAPIVersion("karpenter.sh/v1")
+	np.SetKind("NodePool")
+	np.SetName(name)
+	np.SetLabels(labels)
+	np.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+	return np
+}
+
+func newDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		nodePoolGVR: "NodePoolList",
+	}, objs...)
+}
+
+func TestReaper_Reap(t *testing.T) {
+	g := NewWithT(t)
+	kubeClient := k8sfake.NewClientset(
+		// Objects of the active PodStartup checker.
+		&corev1.Pod{ObjectMeta: objectMeta("podstartup-synthetic-1", "kube-system", map[string]string{podLabelKey: "PodStartup"}, 2*time.Hour)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("clusterhealthmonitor-azurefile-pvc-1", "kube-system",
+			map[string]string{podLabelKey: "PodStartup"}, 2*time.Hour)},
+		// Objects of the removed OldPodStartup checker.
+		&corev1.Pod{ObjectMeta: objectMeta("oldpodstartup-synthetic-1", "kube-system", map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+		&corev1.Pod{ObjectMeta: objectMeta("oldpodstartup-synthetic-2", "kube-system", map[string]string{podLabelKey: "OldPodStartup"}, time.Minute)},
+		&corev1.PersistentVolumeClaim{ObjectMeta: objectMeta("clusterhealthmonitor-azurefile-pvc-2", "kube-system",
+			map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+		&storagev1.StorageClass{ObjectMeta: objectMeta("clusterhealthmonitor-azurefile-sc-2", "",
+			map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+		// Objects that carry the label key but do not look like checker objects.
+		&storagev1.StorageClass{ObjectMeta: objectMeta("managed-csi", "", map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+		&corev1.Pod{ObjectMeta: objectMeta("workload", "default", map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+		// ConfigMap of the renamed APIServer checker, whose label key is only known from the configured label keys.
+		&corev1.ConfigMap{ObjectMeta: objectMeta("apiserver-empty-configmap-1", "kube-system",
+			map[string]string{configMapLabelKey: "APIServer"}, 2*time.Hour)},
+		&corev1.ConfigMap{ObjectMeta: objectMeta("other", "kube-system", nil, 2*time.Hour)},
+	)
+	dynamicClient := newDynamicClient(
+		nodePool("podstartup-nodepool-1", map[string]string{podLabelKey: "1"}, 2*time.Hour),
+		nodePool("oldpodstartup-nodepool-2", map[string]string{podLabelKey: "2"}, 2*time.Hour),
+	)
+	checkers := []checker.Checker{&fakeOwner{name: "PodStartup", labelKey: podLabelKey, namePrefix: "podstartup-nodepool-"}}
+	r := New(kubeClient, dynamicClient, func() []checker.Checker { return checkers }, Config{
+		MinAge:    time.Hour,
+		LabelKeys: []string{configMapLabelKey},
+	})
+	deletedPods := testutil.ToFloat64(metrics.ReaperObjectCounter.WithLabelValues("", KindPod, metrics.ReaperDeleted))
+
+	g.Expect(r.Reap(context.Background())).To(Succeed())
+
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(podNames(pods.Items)).To(ConsistOf("podstartup-synthetic-1", "oldpodstartup-synthetic-2", "workload"))
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(pvcs.Items).To(HaveLen(1))
+	g.Expect(pvcs.Items[0].Name).To(Equal("clusterhealthmonitor-azurefile-pvc-1"))
+	scs, err := kubeClient.StorageV1().StorageClasses().List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(scs.Items).To(HaveLen(1))
+	g.Expect(scs.Items[0].Name).To(Equal("managed-csi"))
+	configMaps, err := kubeClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(configMaps.Items).To(HaveLen(1))
+	g.Expect(configMaps.Items[0].Name).To(Equal("other"))
+	nodePools, err := dynamicClient.Resource(nodePoolGVR).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nodePools.Items).To(HaveLen(1))
+	g.Expect(nodePools.Items[0].GetName()).To(Equal("podstartup-nodepool-1"))
+	g.Expect(testutil.ToFloat64(metrics.ReaperObjectCounter.WithLabelValues("", KindPod, metrics.ReaperDeleted)) - deletedPods).To(Equal(1.0))
+}
+
+func TestReaper_ReapFailures(t *testing.T) {
+	g := NewWithT(t)
+	kubeClient := k8sfake.NewClientset(
+		&corev1.Pod{ObjectMeta: objectMeta("oldpodstartup-synthetic-1", "kube-system", map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+	)
+	kubeClient.PrependReactor("delete", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("error deleting pod")
+	})
+	kubeClient.PrependReactor("list", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("error listing configmaps")
+	})
+	r := New(kubeClient, newDynamicClient(), func() []checker.Checker { return nil }, Config{
+		MinAge:    time.Hour,
+		LabelKeys: []string{podLabelKey},
+	})
+	found := testutil.ToFloat64(metrics.ReaperObjectCounter.WithLabelValues("", KindPod, metrics.ReaperFound))
+	failed := testutil.ToFloat64(metrics.ReaperObjectCounter.WithLabelValues("", KindPod, metrics.ReaperFailed))
+
+	err := r.Reap(context.Background())
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("error deleting pod"))
+	g.Expect(err.Error()).To(ContainSubstring("error listing configmaps"))
+	g.Expect(testutil.ToFloat64(metrics.ReaperObjectCounter.WithLabelValues("", KindPod, metrics.ReaperFound)) - found).To(Equal(1.0))
+	g.Expect(testutil.ToFloat64(metrics.ReaperObjectCounter.WithLabelValues("", KindPod, metrics.ReaperFailed)) - failed).To(Equal(1.0))
+}
+
+func TestReaper_NotLeader(t *testing.T) {
+	g := NewWithT(t)
+	kubeClient := k8sfake.NewClientset(
+		&corev1.Pod{ObjectMeta: objectMeta("oldpodstartup-synthetic-1", "kube-system", map[string]string{podLabelKey: "OldPodStartup"}, 2*time.Hour)},
+	)
+	r := New(kubeClient, newDynamicClient(), func() []checker.Checker { return nil }, Config{MinAge: time.Hour, LabelKeys: []string{podLabelKey}})
+	r.SetIsLeader(func() bool { return false })
+	g.Expect(r.Reap(context.Background())).To(Succeed())
+	g.Expect(kubeClient.Actions()).To(BeEmpty())
+}
+
+func TestReaper_RemembersLabelKeys(t *testing.T) {
+	g := NewWithT(t)
+	kubeClient := k8sfake.NewClientset()
+	checkers := []checker.Checker{&fakeOwner{name: "APIServer", labelKey: configMapLabelKey}}
+	r := New(kubeClient, newDynamicClient(), func() []checker.Checker { return checkers }, Config{MinAge: time.Hour})
+	g.Expect(r.Reap(context.Background())).To(Succeed())
+
+	// The checker is removed, its ConfigMaps are still found with the label key of the previous pass.
+	checkers = nil
+	_, err := kubeClient.CoreV1().ConfigMaps("kube-system").Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: objectMeta("apiserver-empty-configmap-1", "kube-system", map[string]string{configMapLabelKey: "APIServer"}, 2*time.Hour),
+	}, metav1.CreateOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(r.Reap(context.Background())).To(Succeed())
+	configMaps, err := kubeClient.CoreV1().ConfigMaps(metav1.NamespaceAll).List(context.Background(), metav1.ListOptions{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(configMaps.Items).To(BeEmpty())
+}
+
+func podNames(pods []corev1.Pod) []string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
@@ -2,10 +2,18 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/Azure/cluster-health-monitor/pkg/checker"
-	"golang.org/x/sync/errgroup"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
 	"k8s.io/klog/v2"
 )
 
@@ -17,56 +25,579 @@ type CheckerSchedule struct {
 	Timeout time.Duration
 	// Checker is the actual health checker that will be run according to the schedule.
 	Checker checker.Checker
+	// RunOnStart defines whether the checker runs as soon as it is started, instead of after the first interval.
+	RunOnStart bool
+	// Jitter defines the maximum random delay added to every run.
+	Jitter time.Duration
+	// OverlapPolicy defines what to do when a run is due while a previous run is still in progress. It defaults to
+	// config.OverlapPolicySkip.
+	OverlapPolicy config.OverlapPolicy
+	// MaxConcurrentRuns defines the maximum number of runs in progress with config.OverlapPolicyConcurrent. It defaults to 2.
+	MaxConcurrentRuns int
+}
+
+const (
+	// defaultMaxConcurrentRuns is the maximum number of runs in progress with config.OverlapPolicyConcurrent if none is set.
+	defaultMaxConcurrentRuns = 2
+	// maxPanicBackoff caps the time a checker is backed off for after consecutive panicking runs.
+	maxPanicBackoff = 30 * time.Minute
+)
+
+var (
+	// ErrCheckerNotFound is returned for operations on a checker that is not scheduled.
+	ErrCheckerNotFound = errors.New("checker not found")
+	// ErrRunConditionNotMet is returned when a checker is triggered while the run condition prevents it from running, e.g. a checker that
+	// creates resources on a replica that is not the leader.
+	ErrRunConditionNotMet = errors.New("run condition not met")
+)
+
+// runningSchedule is a checker schedule whose scheduling loop has been started.
+type runningSchedule struct {
+	CheckerSchedule
+	// stop is closed to stop the scheduling loop. A run in progress is allowed to complete.
+	stop chan struct{}
+	// done is closed once the scheduling loop has returned.
+	done chan struct{}
+	// offset delays the first run of the checker to stagger the checkers started together.
+	offset time.Duration
+	// paused is whether scheduled runs are skipped. It is guarded by the mutex of the scheduler.
+	paused bool
 }
 
 // NewScheduler creates a new Scheduler instance.
 func NewScheduler(chkSchedules []CheckerSchedule) *Scheduler {
 	return &Scheduler{
 		chkSchedules: chkSchedules,
+		running:      make(map[string]*runningSchedule),
+		heartbeats:   make(map[string]time.Time),
+		stopped:      make(chan struct{}),
 	}
 }
 
 // Scheduler manages and runs a set of checkers periodically.
 type Scheduler struct {
+	// chkSchedules holds the schedules to start when the scheduler is started.
 	chkSchedules []CheckerSchedule
+
+	mu sync.RWMutex
+	// ctx is the context the scheduling loops and runs use. It is derived from the context the scheduler was started with, and nil until
+	// Start is called.
+	ctx context.Context
+	// cancel cancels ctx to abort the runs in progress.
+	cancel context.CancelFunc
+	// stopped is closed by Stop. No checker is started, triggered or removed once the scheduler is stopped.
+	stopped chan struct{}
+	// wg tracks the scheduling loops of all running schedules.
+	wg sync.WaitGroup
+	// running holds the running schedules, keyed by checker name.
+	running map[string]*runningSchedule
+	// heartbeats holds the last time each checker's scheduling loop was active, keyed by checker name.
+	heartbeats map[string]time.Time
+	// runCondition decides whether a checker may run. All checkers run if it is nil.
+	runCondition func(chk checker.Checker) bool
+	// startStagger is the window across which the first runs of the checkers started by Start are spread.
+	startStagger time.Duration
+	// cluster is the name of the cluster the checkers check. It labels the results and metrics of their runs.
+	cluster string
+}
+
+// SetStartStagger spreads the first runs of the checkers started by Start evenly across stagger, so that they do not all hit the API
+// server at once. It must be called before Start. Checkers added after Start are not delayed.
+func (r *Scheduler) SetStartStagger(stagger time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.startStagger = stagger
+}
+
+// SetCluster sets the name of the cluster the checkers of the scheduler check, so that the results and metrics of their runs are labeled
+// with it. It must be called before Start.
+func (r *Scheduler) SetCluster(cluster string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cluster = cluster
+}
+
+// Cluster returns the name of the cluster the checkers of the scheduler check.
+func (r *Scheduler) Cluster() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cluster
+}
+
+// SetRunCondition sets a condition that is evaluated before every scheduled run and before garbage collecting a removed checker. Runs
+// for which the condition is false are skipped, e.g. checkers that create resources on a replica that is not the leader.
+func (r *Scheduler) SetRunCondition(cond func(chk checker.Checker) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runCondition = cond
+}
+
+func (r *Scheduler) canRun(chk checker.Checker) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.runCondition == nil || r.runCondition(chk)
 }
 
-// Start starts all checkers according to their configured intervals and timeouts.
+// Start starts all checkers according to their configured intervals and timeouts. It blocks until ctx is done or Stop is called, and
+// all scheduling loops have returned.
 func (r *Scheduler) Start(ctx context.Context) error {
-	var g errgroup.Group
-	for _, chkSch := range r.chkSchedules {
-		g.Go(func() error {
-			return r.scheduleChecker(ctx, chkSch)
+	r.mu.Lock()
+	if r.ctx != nil {
+		r.mu.Unlock()
+		return errors.New("scheduler already started")
+	}
+	runCtx, cancel := context.WithCancel(checker.WithCluster(ctx, r.cluster))
+	defer cancel()
+	r.ctx, r.cancel = runCtx, cancel
+	for i, chkSch := range r.chkSchedules {
+		offset := r.startStagger * time.Duration(i) / time.Duration(len(r.chkSchedules))
+		if err := r.startLocked(chkSch, offset); err != nil {
+			r.mu.Unlock()
+			return err
+		}
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case <-r.stopped:
+	}
+	r.wg.Wait()
+	return ctx.Err()
+}
+
+// Stop stops scheduling new runs and waits for the runs in progress to complete. If ctx is done before they complete, the runs are aborted
+// by canceling their context, and Stop waits for them to return. Checkers cannot be added, removed or triggered once the scheduler is
+// stopped. It is a no-op if the scheduler has not been started or is already stopped.
+func (r *Scheduler) Stop(ctx context.Context) {
+	r.mu.Lock()
+	if r.ctx == nil || r.isStopped() {
+		r.mu.Unlock()
+		return
+	}
+	close(r.stopped)
+	for _, rs := range r.running {
+		close(rs.stop)
+	}
+	cancel := r.cancel
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		klog.InfoS("Stopped scheduler, the runs in progress completed")
+	case <-ctx.Done():
+		klog.InfoS("Aborting checker runs in progress")
+		cancel()
+		<-done
+		klog.InfoS("Stopped scheduler, the runs in progress were aborted")
+	}
+}
+
+// isStopped returns whether Stop has been called.
+func (r *Scheduler) isStopped() bool {
+	select {
+	case <-r.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sweep deletes all resources created by the checkers that implement checker.Sweeper, regardless of their age. Checkers for which the run
+// condition is false are skipped, e.g. on a replica that is not the leader. It is meant to be called on shutdown after Stop, once no run
+// is in progress, so that no synthetic resources are left behind.
+func (r *Scheduler) Sweep(ctx context.Context) error {
+	var errs []error
+	for _, chk := range r.Checkers() {
+		sweeper, ok := chk.(checker.Sweeper)
+		if !ok || !r.canRun(chk) {
+			continue
+		}
+		if err := sweeper.Sweep(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sweep resources of checker %q: %w", chk.Name(), err))
+			continue
+		}
+		klog.InfoS("Swept checker resources", "name", chk.Name(), "type", string(chk.Type()))
+	}
+	return errors.Join(errs...)
+}
+
+// Add adds a checker schedule to the scheduler, starting it right away if the scheduler is already started. The checker's name must not
+// be in use by another schedule.
+func (r *Scheduler) Add(chkSch CheckerSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ctx == nil {
+		for _, existing := range r.chkSchedules {
+			if existing.Checker.Name() == chkSch.Checker.Name() {
+				return fmt.Errorf("checker %q is already scheduled", chkSch.Checker.Name())
+			}
+		}
+		r.chkSchedules = append(r.chkSchedules, chkSch)
+		return nil
+	}
+	if r.isStopped() {
+		return errors.New("scheduler is stopped")
+	}
+	return r.startLocked(chkSch, 0)
+}
+
+// Remove removes the schedule of the named checker. If the schedule is running, it is stopped and a run in progress is allowed to
+// complete. If the checker implements checker.GarbageCollector, it then garbage collects the resources left behind by the checker. It is
+// a no-op if there is no schedule for the checker.
+func (r *Scheduler) Remove(checkerName string) {
+	r.mu.Lock()
+	if r.ctx == nil {
+		r.chkSchedules = slices.DeleteFunc(r.chkSchedules, func(chkSch CheckerSchedule) bool {
+			return chkSch.Checker.Name() == checkerName
 		})
+		r.mu.Unlock()
+		return
+	}
+	if r.isStopped() {
+		r.mu.Unlock()
+		return
+	}
+	rs, ok := r.running[checkerName]
+	if ok {
+		delete(r.running, checkerName)
+		delete(r.heartbeats, checkerName)
+	}
+	ctx := r.ctx
+	r.mu.Unlock()
+	if !ok {
+		return
 	}
-	return g.Wait()
+
+	close(rs.stop)
+	<-rs.done
+
+	if gc, ok := rs.Checker.(checker.GarbageCollector); ok && r.canRun(rs.Checker) {
+		gcCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rs.Timeout)
+		defer cancel()
+		if err := gc.GarbageCollect(gcCtx); err != nil {
+			klog.ErrorS(err, "Failed to garbage collect resources of removed checker", "name", checkerName)
+		}
+	}
+	klog.InfoS("Removed checker schedule", "name", checkerName, "type", string(rs.Checker.Type()))
 }
 
-func (r *Scheduler) scheduleChecker(ctx context.Context, chkSch CheckerSchedule) error {
-	ticker := time.NewTicker(chkSch.Interval)
-	defer ticker.Stop()
+// startLocked starts the scheduling loop of a checker schedule. r.mu must be held.
+func (r *Scheduler) startLocked(chkSch CheckerSchedule, offset time.Duration) error {
+	name := chkSch.Checker.Name()
+	if _, exists := r.running[name]; exists {
+		return fmt.Errorf("checker %q is already scheduled", name)
+	}
+	rs := &runningSchedule{
+		CheckerSchedule: chkSch,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+		offset:          offset,
+	}
+	r.running[name] = rs
+	r.heartbeats[name] = time.Now()
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer close(rs.done)
+		r.scheduleChecker(r.ctx, rs)
+	}()
+	return nil
+}
 
-	checkerName := chkSch.Checker.Name()
-	checkerType := string(chkSch.Checker.Type())
+// Checkers returns the checkers managed by the scheduler, sorted by name.
+func (r *Scheduler) Checkers() []checker.Checker {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var chks []checker.Checker
+	if r.ctx == nil {
+		for _, chkSch := range r.chkSchedules {
+			chks = append(chks, chkSch.Checker)
+		}
+	} else {
+		for _, rs := range r.running {
+			chks = append(chks, rs.Checker)
+		}
+	}
+	sort.Slice(chks, func(i, j int) bool {
+		return chks[i].Name() < chks[j].Name()
+	})
+	return chks
+}
+
+// Paused returns whether the named checker is paused.
+func (r *Scheduler) Paused(checkerName string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rs, ok := r.running[checkerName]
+	return ok && rs.paused
+}
+
+// Pause pauses the schedule of the named checker: its scheduled runs are skipped until it is resumed, a run in progress is allowed to
+// complete. The schedule stays paused until Resume is called or the checker is removed, e.g. because its configuration changed. It
+// returns ErrCheckerNotFound if the checker is not scheduled.
+func (r *Scheduler) Pause(checkerName string) error {
+	return r.setPaused(checkerName, true)
+}
+
+// Resume resumes the schedule of the named checker after Pause. The next run happens at the next scheduled time. It returns
+// ErrCheckerNotFound if the checker is not scheduled.
+func (r *Scheduler) Resume(checkerName string) error {
+	return r.setPaused(checkerName, false)
+}
+
+func (r *Scheduler) setPaused(checkerName string, paused bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, ok := r.running[checkerName]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrCheckerNotFound, checkerName)
+	}
+	if rs.paused != paused {
+		rs.paused = paused
+		klog.InfoS("Changed checker schedule", "name", checkerName, "type", string(rs.Checker.Type()), "paused", paused)
+	}
+	return nil
+}
+
+// RunNow runs the named checker once with its timeout, regardless of its schedule and whether it is paused, and returns the results
+// recorded during the run. The run is recorded like a scheduled run but does not count towards the overlap policy of the schedule. It
+// returns ErrCheckerNotFound if the checker is not scheduled, and ErrRunConditionNotMet if the run condition prevents it from running.
+// Stop waits for triggered runs like for scheduled runs, and aborts them once its context is done.
+func (r *Scheduler) RunNow(ctx context.Context, checkerName string) ([]checker.ResultRecord, error) {
+	r.mu.RLock()
+	rs, ok := r.running[checkerName]
+	stopping := r.isStopped() || (r.ctx != nil && r.ctx.Err() != nil)
+	if ok && !stopping {
+		// The run is added to the wait group while the lock is held, so that it cannot start once Stop waits for the runs in progress.
+		r.wg.Add(1)
+	}
+	schedCtx := r.ctx
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrCheckerNotFound, checkerName)
+	}
+	if stopping {
+		return nil, errors.New("scheduler is stopped")
+	}
+	defer r.wg.Done()
+	if !r.canRun(rs.Checker) {
+		return nil, fmt.Errorf("%w: checker %q", ErrRunConditionNotMet, checkerName)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopAbort := context.AfterFunc(schedCtx, cancel)
+	defer stopAbort()
+	klog.InfoS("Triggered checker run", "name", checkerName, "type", string(rs.Checker.Type()))
+	records, _ := r.run(checker.WithCluster(ctx, r.Cluster()), rs)
+	return records, nil
+}
+
+// Healthy returns an error if the scheduling loop of any checker has not been active within the expected time. A loop is expected to be
+// active at least once per interval plus jitter, and the first run may be delayed by the start stagger. Runs do not delay the loop as
+// they happen in the background.
+func (r *Scheduler) Healthy() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.ctx == nil {
+		return errors.New("scheduler has not been started")
+	}
+	for name, rs := range r.running {
+		last, ok := r.heartbeats[name]
+		if !ok {
+			return fmt.Errorf("checker %q has not been scheduled", name)
+		}
+		if since := time.Since(last); since > 2*(rs.Interval+rs.Jitter)+rs.offset {
+			return fmt.Errorf("checker %q has not been scheduled for %s", name, since.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+func (r *Scheduler) heartbeat(checkerName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.running[checkerName]; ok {
+		r.heartbeats[checkerName] = time.Now()
+	}
+}
+
+// scheduleChecker runs a checker every interval until the schedule is stopped or ctx is done. Runs happen in the background so that a
+// slow run does not delay the schedule, a run that is due while previous runs are in progress is handled according to the overlap policy.
+// After a run panics, runs that are due are skipped until the panic backoff has passed. Runs in progress are allowed to complete before
+// scheduleChecker returns.
+func (r *Scheduler) scheduleChecker(ctx context.Context, rs *runningSchedule) {
+	firstDelay := rs.offset + jitter(rs.Jitter)
+	if !rs.RunOnStart {
+		firstDelay += rs.Interval
+	}
+	timer := time.NewTimer(firstDelay)
+	defer timer.Stop()
+
+	maxConcurrentRuns := rs.MaxConcurrentRuns
+	if maxConcurrentRuns <= 0 {
+		maxConcurrentRuns = defaultMaxConcurrentRuns
+	}
+	// runDone receives whether a finished run panicked.
+	runDone := make(chan bool)
+	inFlight, queued := 0, false
+	// panics is the number of consecutive panicking runs, no run starts before backoffUntil.
+	panics := 0
+	var backoffUntil time.Time
+	startRun := func() {
+		inFlight++
+		go func() {
+			runDone <- r.runChecker(ctx, rs)
+		}()
+	}
+	defer func() {
+		for ; inFlight > 0; inFlight-- {
+			<-runDone
+		}
+	}()
+
+	checkerName := rs.Checker.Name()
+	checkerType := string(rs.Checker.Type())
 	klog.InfoS("Started checker scheduler",
 		"name", checkerName,
 		"type", checkerType,
-		"interval", chkSch.Interval.String(),
-		"timeout", chkSch.Timeout.String())
+		"interval", rs.Interval.String(),
+		"timeout", rs.Timeout.String(),
+		"firstRunIn", firstDelay.String())
 	for {
 		select {
-		case <-ticker.C:
-			func() {
-				runCtx, cancel := context.WithTimeout(ctx, chkSch.Timeout)
-				defer cancel()
-				chkSch.Checker.Run(runCtx)
-			}()
-			klog.V(3).InfoS("Ran scheduled check",
-				"name", checkerName,
-				"type", checkerType)
+		case <-timer.C:
+			r.heartbeat(checkerName)
+			timer.Reset(rs.Interval + jitter(rs.Jitter))
+			switch {
+			case time.Now().Before(backoffUntil):
+				checker.RecordMissedRun(ctx, rs.Checker, metrics.MissedRunPanicBackoff)
+			case inFlight == 0:
+				startRun()
+			case rs.OverlapPolicy == config.OverlapPolicyQueue:
+				if queued {
+					checker.RecordMissedRun(ctx, rs.Checker, metrics.MissedRunQueueFull)
+				}
+				queued = true
+			case rs.OverlapPolicy == config.OverlapPolicyConcurrent:
+				if inFlight >= maxConcurrentRuns {
+					checker.RecordMissedRun(ctx, rs.Checker, metrics.MissedRunConcurrencyLimit)
+					break
+				}
+				startRun()
+			default:
+				checker.RecordMissedRun(ctx, rs.Checker, metrics.MissedRunOverlap)
+			}
+		case panicked := <-runDone:
+			inFlight--
+			if !panicked {
+				panics = 0
+			} else {
+				panics++
+				backoff := panicBackoff(rs.Interval, panics)
+				backoffUntil = time.Now().Add(backoff)
+				queued = false
+				klog.InfoS("Backing off checker after panic", "name", checkerName, "type", checkerType, "panics", panics,
+					"backoff", backoff.String())
+			}
+			if queued {
+				queued = false
+				startRun()
+			}
+		case <-rs.stop:
+			klog.InfoS("Stopped checker scheduler", "name", checkerName, "type", checkerType)
+			return
 		case <-ctx.Done():
 			klog.InfoS("Stopped checker scheduler", "name", checkerName, "type", checkerType)
-			return ctx.Err()
+			return
 		}
 	}
 }
+
+// runChecker runs a scheduled run of a checker, unless the schedule is paused or the run condition prevents it. It returns whether the
+// run panicked.
+func (r *Scheduler) runChecker(ctx context.Context, rs *runningSchedule) bool {
+	checkerName := rs.Checker.Name()
+	checkerType := string(rs.Checker.Type())
+	if r.Paused(checkerName) {
+		klog.V(3).InfoS("Skipped scheduled check of paused checker",
+			"name", checkerName,
+			"type", checkerType)
+		return false
+	}
+	if !r.canRun(rs.Checker) {
+		klog.V(3).InfoS("Skipped scheduled check due to run condition",
+			"name", checkerName,
+			"type", checkerType)
+		return false
+	}
+
+	_, panicked := r.run(ctx, rs)
+	klog.V(3).InfoS("Ran scheduled check",
+		"name", checkerName,
+		"type", checkerType)
+	return panicked
+}
+
+// run runs a checker once with its timeout. A panic in the checker is recovered and recorded as a result of the checker. It returns the
+// results recorded during the run and whether the run panicked.
+func (r *Scheduler) run(ctx context.Context, rs *runningSchedule) ([]checker.ResultRecord, bool) {
+	runCtx, cancel := context.WithTimeout(ctx, rs.Timeout)
+	defer cancel()
+	runCtx = checker.WithRunStatus(runCtx)
+	runCtx, span := checker.StartRunSpan(runCtx, rs.Checker)
+	defer span.End()
+	start := time.Now()
+	value, stack := runRecovered(runCtx, rs.Checker)
+	if stack != nil {
+		err := &checker.PanicError{Value: value}
+		klog.ErrorS(err, "Recovered from panic in checker run",
+			"name", rs.Checker.Name(),
+			"type", string(rs.Checker.Type()),
+			"stack", string(stack))
+		span.RecordError(err)
+		checker.RecordPanic(runCtx, rs.Checker, value)
+		checker.RecordRunDuration(runCtx, rs.Checker, time.Since(start))
+		return checker.RunResults(runCtx), true
+	}
+	checker.RecordRunDuration(runCtx, rs.Checker, time.Since(start))
+	checker.RecordRunFailure(runCtx, rs.Checker)
+	checker.ForgetStaleTargets(runCtx, rs.Checker)
+	return checker.RunResults(runCtx), false
+}
+
+// runRecovered runs a checker and recovers from a panic in its Run. It returns the value the checker panicked with and the stack trace of
+// the panic, stack is nil if the checker did not panic.
+func runRecovered(ctx context.Context, chk checker.Checker) (value any, stack []byte) {
+	defer func() {
+		if v := recover(); v != nil {
+			value, stack = v, debug.Stack()
+		}
+	}()
+	chk.Run(ctx)
+	return nil, nil
+}
+
+// panicBackoff returns the time a checker is backed off for after the given number of consecutive panicking runs. It doubles with every
+// panic, starting from the interval, and is capped at maxPanicBackoff or the interval if that is longer.
+func panicBackoff(interval time.Duration, panics int) time.Duration {
+	backoff := interval
+	for i := 1; i < panics && backoff < maxPanicBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, max(maxPanicBackoff, interval))
+}
+
+// jitter returns a random duration in [0, maxJitter).
+func jitter(maxJitter time.Duration) time.Duration {
+	if maxJitter <= 0 {
+		return 0
+	}
+	return rand.N(maxJitter)
+}
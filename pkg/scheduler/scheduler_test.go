@@ -7,23 +7,47 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
 	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 type fakeChecker struct {
 	name     string
 	runCount int32
 	delay    time.Duration
+	// active and maxActive track the number of runs in progress.
+	active    int32
+	maxActive int32
+	// panicValue is the value every run panics with if it is not nil.
+	panicValue any
+	// result is the result every run records if it is not nil.
+	result *checker.Result
 }
 
 func (f *fakeChecker) Name() string { return f.name }
 func (f *fakeChecker) Run(ctx context.Context) {
 	fmt.Println("Running fake checker:", f.name)
 	atomic.AddInt32(&f.runCount, 1)
+	active := atomic.AddInt32(&f.active, 1)
+	defer atomic.AddInt32(&f.active, -1)
+	for {
+		maxActive := atomic.LoadInt32(&f.maxActive)
+		if active <= maxActive || atomic.CompareAndSwapInt32(&f.maxActive, maxActive, active) {
+			break
+		}
+	}
 	if f.delay > 0 {
 		time.Sleep(f.delay)
 	}
+	if f.panicValue != nil {
+		panic(f.panicValue)
+	}
+	if f.result != nil {
+		checker.RecordResult(ctx, f, f.result, nil)
+	}
 }
 func (f *fakeChecker) Type() config.CheckerType { return config.CheckerType("fake") }
 
@@ -44,3 +68,444 @@ func TestScheduler_Run_Periodic(t *testing.T) {
 	_ = scheduler.Start(ctx)
 	g.Expect(fakeChk.runCount).To(BeNumerically(">=", 2))
 }
+
+func TestScheduler_Healthy(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	fakeChk := &fakeChecker{name: "healthy1", delay: 0}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{
+			Interval: 20 * time.Millisecond,
+			Timeout:  10 * time.Millisecond,
+			Checker:  fakeChk,
+		},
+	})
+	g.Expect(scheduler.Healthy()).To(HaveOccurred(), "scheduler should not be healthy before it is started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = scheduler.Start(ctx)
+		close(done)
+	}()
+	g.Eventually(scheduler.Healthy, time.Second, 10*time.Millisecond).Should(Succeed())
+	g.Expect(scheduler.Checkers()).To(ConsistOf(fakeChk))
+
+	cancel()
+	<-done
+	g.Eventually(scheduler.Healthy, time.Second, 10*time.Millisecond).Should(HaveOccurred(), "scheduler should not be healthy once it is stopped")
+}
+
+type fakeGCChecker struct {
+	fakeChecker
+	gcCount int32
+}
+
+func (f *fakeGCChecker) GarbageCollect(ctx context.Context) error {
+	atomic.AddInt32(&f.gcCount, 1)
+	return nil
+}
+
+func TestScheduler_AddRemove(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	initialChk := &fakeGCChecker{fakeChecker: fakeChecker{name: "initial"}}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{
+			Interval: 10 * time.Millisecond,
+			Timeout:  10 * time.Millisecond,
+			Checker:  initialChk,
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(func() int32 { return atomic.LoadInt32(&initialChk.runCount) }, time.Second, 10*time.Millisecond).Should(BeNumerically(">=", 1))
+
+	addedChk := &fakeChecker{name: "added"}
+	g.Expect(scheduler.Add(CheckerSchedule{Interval: 10 * time.Millisecond, Timeout: 10 * time.Millisecond, Checker: addedChk})).To(Succeed())
+	g.Expect(scheduler.Add(CheckerSchedule{Interval: 10 * time.Millisecond, Timeout: 10 * time.Millisecond, Checker: addedChk})).ToNot(Succeed())
+	g.Eventually(func() int32 { return atomic.LoadInt32(&addedChk.runCount) }, time.Second, 10*time.Millisecond).Should(BeNumerically(">=", 1))
+	g.Expect(scheduler.Checkers()).To(Equal([]checker.Checker{addedChk, initialChk}))
+
+	scheduler.Remove("initial")
+	g.Expect(atomic.LoadInt32(&initialChk.gcCount)).To(Equal(int32(1)))
+	g.Expect(scheduler.Checkers()).To(Equal([]checker.Checker{addedChk}))
+	runCount := atomic.LoadInt32(&initialChk.runCount)
+	g.Consistently(func() int32 { return atomic.LoadInt32(&initialChk.runCount) }, 50*time.Millisecond, 10*time.Millisecond).Should(Equal(runCount))
+}
+
+func TestScheduler_RunCondition(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	allowedChk := &fakeChecker{name: "allowed"}
+	blockedChk := &fakeGCChecker{fakeChecker: fakeChecker{name: "blocked"}}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: 10 * time.Millisecond, Timeout: 10 * time.Millisecond, Checker: allowedChk},
+		{Interval: 10 * time.Millisecond, Timeout: 10 * time.Millisecond, Checker: blockedChk},
+	})
+	var allowAll atomic.Bool
+	scheduler.SetRunCondition(func(chk checker.Checker) bool {
+		return allowAll.Load() || chk.Name() == "allowed"
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(func() int32 { return atomic.LoadInt32(&allowedChk.runCount) }, time.Second, 10*time.Millisecond).Should(BeNumerically(">=", 2))
+	g.Expect(atomic.LoadInt32(&blockedChk.runCount)).To(BeZero())
+	g.Expect(scheduler.Healthy()).To(Succeed(), "skipped runs still count as scheduling loop activity")
+
+	allowAll.Store(true)
+	g.Eventually(func() int32 { return atomic.LoadInt32(&blockedChk.runCount) }, time.Second, 10*time.Millisecond).Should(BeNumerically(">=", 1))
+
+	allowAll.Store(false)
+	scheduler.Remove("blocked")
+	g.Expect(atomic.LoadInt32(&blockedChk.gcCount)).To(BeZero(), "garbage collection is subject to the run condition")
+}
+
+func TestScheduler_PauseResume(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	fakeChk := &fakeChecker{name: "pausable"}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: 10 * time.Millisecond, Timeout: 10 * time.Millisecond, Checker: fakeChk},
+	})
+	g.Expect(scheduler.Pause("pausable")).To(MatchError(ErrCheckerNotFound), "checkers can only be paused once started")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(func() int32 { return atomic.LoadInt32(&fakeChk.runCount) }, time.Second, 10*time.Millisecond).Should(BeNumerically(">=", 1))
+
+	g.Expect(scheduler.Pause("pausable")).To(Succeed())
+	g.Expect(scheduler.Paused("pausable")).To(BeTrue())
+	time.Sleep(20 * time.Millisecond)
+	runCount := atomic.LoadInt32(&fakeChk.runCount)
+	g.Consistently(func() int32 { return atomic.LoadInt32(&fakeChk.runCount) }, 50*time.Millisecond, 10*time.Millisecond).Should(Equal(runCount))
+	g.Expect(scheduler.Healthy()).To(Succeed(), "paused checkers are still scheduled")
+
+	g.Expect(scheduler.Resume("pausable")).To(Succeed())
+	g.Expect(scheduler.Paused("pausable")).To(BeFalse())
+	g.Eventually(func() int32 { return atomic.LoadInt32(&fakeChk.runCount) }, time.Second, 10*time.Millisecond).Should(BeNumerically(">", runCount))
+	g.Expect(scheduler.Resume("missing")).To(MatchError(ErrCheckerNotFound))
+}
+
+func TestScheduler_RunNow(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	fakeChk := &fakeChecker{name: "triggered", result: checker.Unhealthy("CODE", "message")}
+	blockedChk := &fakeChecker{name: "triggered-blocked"}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: time.Hour, Timeout: time.Second, Checker: fakeChk},
+		{Interval: time.Hour, Timeout: time.Second, Checker: blockedChk},
+	})
+	scheduler.SetRunCondition(func(chk checker.Checker) bool {
+		return chk.Name() != "triggered-blocked"
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(scheduler.Healthy, time.Second, 10*time.Millisecond).Should(Succeed())
+	g.Expect(scheduler.Pause("triggered")).To(Succeed())
+
+	records, err := scheduler.RunNow(context.Background(), "triggered")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(1), "paused checkers can be triggered")
+	g.Expect(records[0].Result).To(Equal(checker.Unhealthy("CODE", "message")))
+	g.Expect(records[0].RunID).NotTo(BeEmpty())
+	g.Expect(atomic.LoadInt32(&fakeChk.runCount)).To(Equal(int32(1)))
+
+	_, err = scheduler.RunNow(context.Background(), "triggered-blocked")
+	g.Expect(err).To(MatchError(ErrRunConditionNotMet))
+	g.Expect(atomic.LoadInt32(&blockedChk.runCount)).To(BeZero())
+	_, err = scheduler.RunNow(context.Background(), "missing")
+	g.Expect(err).To(MatchError(ErrCheckerNotFound))
+}
+
+func TestScheduler_Cluster(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	fakeChk := &fakeChecker{name: "cluster", result: checker.Healthy()}
+	scheduler := NewScheduler([]CheckerSchedule{{Interval: time.Hour, Timeout: time.Second, Checker: fakeChk, RunOnStart: true}})
+	scheduler.SetCluster("cluster-a")
+	g.Expect(scheduler.Cluster()).To(Equal("cluster-a"))
+	t.Cleanup(func() {
+		checker.ClearChecker("cluster-a", &config.CheckerConfig{Name: fakeChk.name, Type: fakeChk.Type()})
+	})
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(func() bool {
+		record, ok := checker.LatestResult("cluster-a", "cluster")
+		return ok && !record.Timestamp.Before(start)
+	}, time.Second, 10*time.Millisecond).Should(BeTrue(), "scheduled runs are labeled with the cluster")
+
+	records, err := scheduler.RunNow(context.Background(), "cluster")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(records).To(HaveLen(1))
+	g.Expect(records[0].Cluster).To(Equal("cluster-a"), "triggered runs are labeled with the cluster")
+}
+
+// fakeBlockingChecker blocks every run until its context is done and counts the aborted runs. It also counts the calls to Sweep.
+type fakeBlockingChecker struct {
+	fakeChecker
+	started    chan struct{}
+	aborted    int32
+	sweepCount int32
+}
+
+func (f *fakeBlockingChecker) Run(ctx context.Context) {
+	close(f.started)
+	<-ctx.Done()
+	atomic.AddInt32(&f.aborted, 1)
+}
+
+func (f *fakeBlockingChecker) Sweep(ctx context.Context) error {
+	atomic.AddInt32(&f.sweepCount, 1)
+	return nil
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	slowChk := &fakeChecker{name: "slow", delay: 100 * time.Millisecond}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: time.Hour, Timeout: time.Second, Checker: slowChk, RunOnStart: true},
+	})
+
+	startErr := make(chan error, 1)
+	go func() {
+		startErr <- scheduler.Start(context.Background())
+	}()
+	g.Eventually(func() int32 { return atomic.LoadInt32(&slowChk.active) }, time.Second, 10*time.Millisecond).Should(Equal(int32(1)))
+
+	scheduler.Stop(context.Background())
+	g.Expect(atomic.LoadInt32(&slowChk.active)).To(BeZero(), "the run in progress completes before Stop returns")
+	g.Expect(atomic.LoadInt32(&slowChk.runCount)).To(Equal(int32(1)))
+	g.Eventually(startErr).Should(Receive(BeNil()))
+	g.Expect(scheduler.Add(CheckerSchedule{Interval: time.Hour, Timeout: time.Second, Checker: &fakeChecker{name: "late"}})).NotTo(Succeed())
+	_, err := scheduler.RunNow(context.Background(), "slow")
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestScheduler_StopAbortsRuns(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	blockingChk := &fakeBlockingChecker{fakeChecker: fakeChecker{name: "blocking"}, started: make(chan struct{})}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: time.Hour, Timeout: time.Hour, Checker: blockingChk, RunOnStart: true},
+	})
+	go func() {
+		_ = scheduler.Start(context.Background())
+	}()
+	g.Eventually(blockingChk.started).Should(BeClosed())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	scheduler.Stop(ctx)
+	g.Expect(atomic.LoadInt32(&blockingChk.aborted)).To(Equal(int32(1)), "the run is aborted once the grace period is over")
+
+	g.Expect(scheduler.Sweep(context.Background())).To(Succeed())
+	g.Expect(atomic.LoadInt32(&blockingChk.sweepCount)).To(Equal(int32(1)))
+	scheduler.SetRunCondition(func(checker.Checker) bool { return false })
+	g.Expect(scheduler.Sweep(context.Background())).To(Succeed())
+	g.Expect(atomic.LoadInt32(&blockingChk.sweepCount)).To(Equal(int32(1)), "sweeping is subject to the run condition")
+}
+
+func TestScheduler_StopWaitsForTriggeredRuns(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	blockingChk := &fakeBlockingChecker{fakeChecker: fakeChecker{name: "triggered-blocking"}, started: make(chan struct{})}
+	scheduler := NewScheduler([]CheckerSchedule{{Interval: time.Hour, Timeout: time.Hour, Checker: blockingChk}})
+	go func() {
+		_ = scheduler.Start(context.Background())
+	}()
+	g.Eventually(scheduler.Healthy, time.Second, 10*time.Millisecond).Should(Succeed())
+
+	runErr := make(chan error, 1)
+	go func() {
+		_, err := scheduler.RunNow(context.Background(), "triggered-blocking")
+		runErr <- err
+	}()
+	g.Eventually(blockingChk.started).Should(BeClosed())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	scheduler.Stop(ctx)
+	g.Expect(atomic.LoadInt32(&blockingChk.aborted)).To(Equal(int32(1)), "the triggered run is aborted once the grace period is over")
+	g.Expect(runErr).To(Receive(BeNil()), "Stop returns once the triggered run returned")
+	_, err := scheduler.RunNow(context.Background(), "triggered-blocking")
+	g.Expect(err).To(MatchError("scheduler is stopped"))
+}
+
+func TestScheduler_RunOnStart(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	onStartChk := &fakeChecker{name: "on-start"}
+	delayedChk := &fakeChecker{name: "delayed"}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: time.Hour, Timeout: time.Second, Checker: onStartChk, RunOnStart: true},
+		{Interval: time.Hour, Timeout: time.Second, Checker: delayedChk},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(func() int32 { return atomic.LoadInt32(&onStartChk.runCount) }, time.Second, 10*time.Millisecond).Should(Equal(int32(1)))
+	g.Consistently(func() int32 { return atomic.LoadInt32(&delayedChk.runCount) }, 100*time.Millisecond, 10*time.Millisecond).Should(BeZero())
+}
+
+func TestScheduler_StartStagger(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	var chkSchs []CheckerSchedule
+	for i := range 4 {
+		chkSchs = append(chkSchs, CheckerSchedule{Interval: time.Hour, Timeout: time.Second, Checker: &fakeChecker{name: fmt.Sprintf("chk%d", i)}})
+	}
+	scheduler := NewScheduler(chkSchs)
+	scheduler.SetStartStagger(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = scheduler.Start(ctx)
+	}()
+	g.Eventually(scheduler.Healthy, time.Second, 10*time.Millisecond).Should(Succeed())
+
+	scheduler.mu.RLock()
+	defer scheduler.mu.RUnlock()
+	for i := range 4 {
+		g.Expect(scheduler.running[fmt.Sprintf("chk%d", i)].offset).To(Equal(time.Duration(i) * 15 * time.Second))
+	}
+}
+
+func TestJitter(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	g.Expect(jitter(0)).To(BeZero())
+	for range 100 {
+		g.Expect(jitter(time.Second)).To(And(BeNumerically(">=", 0), BeNumerically("<", time.Second)))
+	}
+}
+
+func TestScheduler_OverlapPolicy(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name              string
+		policy            config.OverlapPolicy
+		maxConcurrentRuns int
+		expectedMaxActive int32
+		expectedReason    string
+	}{
+		{
+			name:              "skip",
+			expectedMaxActive: 1,
+			expectedReason:    metrics.MissedRunOverlap,
+		},
+		{
+			name:              "queue one",
+			policy:            config.OverlapPolicyQueue,
+			expectedMaxActive: 1,
+			expectedReason:    metrics.MissedRunQueueFull,
+		},
+		{
+			name:              "concurrent with limit",
+			policy:            config.OverlapPolicyConcurrent,
+			maxConcurrentRuns: 3,
+			expectedMaxActive: 3,
+			expectedReason:    metrics.MissedRunConcurrencyLimit,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			fakeChk := &fakeChecker{name: "overlap-" + tc.name, delay: 100 * time.Millisecond}
+			scheduler := NewScheduler([]CheckerSchedule{
+				{
+					Interval:          10 * time.Millisecond,
+					Timeout:           time.Second,
+					Checker:           fakeChk,
+					RunOnStart:        true,
+					OverlapPolicy:     tc.policy,
+					MaxConcurrentRuns: tc.maxConcurrentRuns,
+				},
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+			defer cancel()
+			_ = scheduler.Start(ctx)
+
+			g.Expect(atomic.LoadInt32(&fakeChk.active)).To(BeZero(), "runs in progress complete before the scheduler returns")
+			g.Expect(atomic.LoadInt32(&fakeChk.maxActive)).To(Equal(tc.expectedMaxActive))
+			missed := testutil.ToFloat64(metrics.CheckerMissedRunCounter.WithLabelValues("", "fake", fakeChk.name, tc.expectedReason))
+			g.Expect(missed).To(BeNumerically(">", 0))
+		})
+	}
+}
+
+func TestScheduler_PanicIsolation(t *testing.T) {
+	t.Parallel()
+	g := NewWithT(t)
+	panicking := &fakeChecker{name: "panicking", panicValue: "nil dereference"}
+	healthy := &fakeChecker{name: "not-panicking"}
+	scheduler := NewScheduler([]CheckerSchedule{
+		{Interval: 10 * time.Millisecond, Timeout: time.Second, Checker: panicking, RunOnStart: true},
+		{Interval: 10 * time.Millisecond, Timeout: time.Second, Checker: healthy, RunOnStart: true},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	g.Expect(scheduler.Start(ctx)).To(MatchError(context.DeadlineExceeded))
+
+	g.Expect(atomic.LoadInt32(&panicking.runCount)).To(BeNumerically(">=", 2))
+	g.Expect(atomic.LoadInt32(&panicking.runCount)).To(BeNumerically("<", atomic.LoadInt32(&healthy.runCount)/2),
+		"the panicking checker is backed off while the other checker keeps running")
+	g.Expect(testutil.ToFloat64(metrics.CheckerResultCounter.WithLabelValues("", "fake", panicking.name, metrics.UnknownStatus,
+		metrics.PanicCode))).To(BeNumerically(">=", 2))
+	g.Expect(testutil.ToFloat64(metrics.CheckerRunFailureCounter.WithLabelValues("", "fake", panicking.name,
+		metrics.RunFailurePanic))).To(BeNumerically(">=", 2))
+	g.Expect(testutil.ToFloat64(metrics.CheckerMissedRunCounter.WithLabelValues("", "fake", panicking.name,
+		metrics.MissedRunPanicBackoff))).To(BeNumerically(">", 0))
+	g.Expect(testutil.ToFloat64(metrics.CheckerRunFailureCounter.WithLabelValues("", "fake", healthy.name,
+		metrics.RunFailurePanic))).To(BeZero())
+}
+
+func TestPanicBackoff(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		name     string
+		interval time.Duration
+		panics   int
+		expected time.Duration
+	}{
+		{name: "first panic", interval: time.Minute, panics: 1, expected: time.Minute},
+		{name: "doubles", interval: time.Minute, panics: 3, expected: 4 * time.Minute},
+		{name: "capped", interval: time.Minute, panics: 20, expected: maxPanicBackoff},
+		{name: "interval longer than cap", interval: time.Hour, panics: 3, expected: time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			g := NewWithT(t)
+			g.Expect(panicBackoff(tc.interval, tc.panics)).To(Equal(tc.expected))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+// Package status provides an HTTP API that reports the latest result of every configured checker.
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	"k8s.io/klog/v2"
+)
+
+// Path is the path at which the status API is served.
+const Path = "/api/v1/status"
+
+// Response is the response body of the status API.
+type Response struct {
+	// Checkers holds the status of every configured checker.
+	Checkers []CheckerStatus `json:"checkers"`
+}
+
+// CheckerStatus is the latest result of a single checker.
+type CheckerStatus struct {
+	// Cluster is the name of the cluster the checker checks. It is empty unless the monitor checks several clusters.
+	Cluster string `json:"cluster,omitempty"`
+	// Name is the name of the checker.
+	Name string `json:"name"`
+	// Type is the type of the checker.
+	Type string `json:"type"`
+	// Status is the status of the latest result. It is empty if the checker has not recorded a result yet, and Unknown if the latest run
+	// failed with an error.
+	Status string `json:"status,omitempty"`
+	// ErrorCode is the error code of the latest result if it is not healthy.
+	ErrorCode string `json:"errorCode,omitempty"`
+	// Message is a human-readable message about the latest result, or the error of the latest run.
+	Message string `json:"message,omitempty"`
+	// Timestamp is the time at which the latest result was recorded.
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	// Targets holds the latest result for each target of checkers that record per-target results, e.g. per CoreDNS pod.
+	Targets []TargetStatus `json:"targets,omitempty"`
+}
+
+// TargetStatus is the latest result of a checker for a single target.
+type TargetStatus struct {
+	// Name is the name of the target.
+	Name string `json:"name"`
+	// Status is the status of the latest result for the target.
+	Status string `json:"status"`
+	// ErrorCode is the error code of the latest result for the target if it is not healthy.
+	ErrorCode string `json:"errorCode,omitempty"`
+	// Message is a human-readable message about the latest result for the target.
+	Message string `json:"message,omitempty"`
+	// Timestamp is the time at which the latest result for the target was recorded.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// NewHandler returns an HTTP handler that serves the latest result of every checker of the schedulers returned by schedulers.
+func NewHandler(schedulers func() []*scheduler.Scheduler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resp := Response{Checkers: []CheckerStatus{}}
+		for _, s := range schedulers() {
+			resp.Checkers = append(resp.Checkers, NewResponse(s.Cluster(), s.Checkers()).Checkers...)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			klog.ErrorS(err, "Failed to write status response")
+		}
+	})
+}
+
+// NewResponse returns the latest result of each of the given checkers of the named cluster.
+func NewResponse(cluster string, checkers []checker.Checker) Response {
+	resp := Response{Checkers: []CheckerStatus{}}
+	for _, chk := range checkers {
+		resp.Checkers = append(resp.Checkers, checkerStatus(cluster, chk))
+	}
+	return resp
+}
+
+func checkerStatus(cluster string, chk checker.Checker) CheckerStatus {
+	cs := CheckerStatus{
+		Cluster: cluster,
+		Name:    chk.Name(),
+		Type:    string(chk.Type()),
+	}
+	if record, ok := checker.LatestResult(cluster, chk.Name()); ok {
+		cs.Status, cs.ErrorCode, cs.Message = recordFields(record)
+		cs.Timestamp = &record.Timestamp
+	}
+	for _, record := range checker.LatestTargetResults(cluster, chk.Name()) {
+		ts := TargetStatus{
+			Name:      record.Target,
+			Timestamp: record.Timestamp,
+		}
+		ts.Status, ts.ErrorCode, ts.Message = recordFields(record)
+		cs.Targets = append(cs.Targets, ts)
+	}
+	return cs
+}
+
+// recordFields returns the status, error code and message of a result record.
+func recordFields(record checker.ResultRecord) (string, string, string) {
+	if checker.IsPanic(record.Err) {
+		return metrics.UnknownStatus, metrics.PanicCode, record.Err.Error()
+	}
+	if record.Err != nil {
+		return metrics.UnknownStatus, "", record.Err.Error()
+	}
+	return string(record.Result.Status), record.Result.Detail.Code, record.Result.Detail.Message
+}
@@ -0,0 +1,104 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"github.com/Azure/cluster-health-monitor/pkg/scheduler"
+	. "github.com/onsi/gomega"
+)
+
+type fakeChecker struct{ name string }
+
+func (f *fakeChecker) Name() string             { return f.name }
+func (f *fakeChecker) Run(ctx context.Context)  {}
+func (f *fakeChecker) Type() config.CheckerType { return config.CheckerType("fake") }
+
+func TestNewHandler(t *testing.T) {
+	g := NewWithT(t)
+
+	healthy := &fakeChecker{name: "status-healthy"}
+	unhealthy := &fakeChecker{name: "status-unhealthy"}
+	failed := &fakeChecker{name: "status-failed"}
+	perTarget := &fakeChecker{name: "status-per-target"}
+	notRun := &fakeChecker{name: "status-not-run"}
+	ctx := context.Background()
+	checker.RecordResult(ctx, healthy, checker.Healthy(), nil)
+	checker.RecordResult(ctx, unhealthy, checker.Unhealthy("SomeCode", "some message"), nil)
+	checker.RecordResult(ctx, failed, nil, errors.New("run error"))
+	checker.RecordTargetResult(ctx, perTarget, "pod-b", checker.Unhealthy("PodTimeout", "timed out"), nil)
+	checker.RecordTargetResult(ctx, perTarget, "pod-a", checker.Healthy(), nil)
+	checker.RecordResult(checker.WithCluster(ctx, "cluster-b"), healthy, checker.Unhealthy("OtherCode", "other cluster"), nil)
+
+	schedulers := []*scheduler.Scheduler{
+		newScheduler("", healthy, unhealthy, failed, perTarget, notRun),
+		newScheduler("cluster-b", healthy),
+	}
+	handler := NewHandler(func() []*scheduler.Scheduler { return schedulers })
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+	g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+	var resp Response
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &resp)).To(Succeed())
+	g.Expect(resp.Checkers).To(HaveLen(6))
+
+	// The checkers of each scheduler are sorted by name.
+	g.Expect(resp.Checkers[0].Name).To(Equal("status-failed"))
+	g.Expect(resp.Checkers[0].Status).To(Equal(metrics.UnknownStatus))
+	g.Expect(resp.Checkers[0].Message).To(Equal("run error"))
+
+	g.Expect(resp.Checkers[1].Name).To(Equal("status-healthy"))
+	g.Expect(resp.Checkers[1].Cluster).To(BeEmpty())
+	g.Expect(resp.Checkers[1].Type).To(Equal("fake"))
+	g.Expect(resp.Checkers[1].Status).To(Equal(string(checker.StatusHealthy)))
+	g.Expect(resp.Checkers[1].Timestamp).ToNot(BeNil())
+
+	g.Expect(resp.Checkers[2].Name).To(Equal("status-not-run"))
+	g.Expect(resp.Checkers[2].Status).To(BeEmpty())
+	g.Expect(resp.Checkers[2].Timestamp).To(BeNil())
+
+	g.Expect(resp.Checkers[3].Status).To(BeEmpty())
+	g.Expect(resp.Checkers[3].Targets).To(HaveLen(2))
+	g.Expect(resp.Checkers[3].Targets[0].Name).To(Equal("pod-a"))
+	g.Expect(resp.Checkers[3].Targets[0].Status).To(Equal(string(checker.StatusHealthy)))
+	g.Expect(resp.Checkers[3].Targets[1].Name).To(Equal("pod-b"))
+	g.Expect(resp.Checkers[3].Targets[1].ErrorCode).To(Equal("PodTimeout"))
+
+	g.Expect(resp.Checkers[4].Status).To(Equal(string(checker.StatusUnhealthy)))
+	g.Expect(resp.Checkers[4].ErrorCode).To(Equal("SomeCode"))
+	g.Expect(resp.Checkers[4].Message).To(Equal("some message"))
+
+	// A checker with the same name in another cluster reports its own result.
+	g.Expect(resp.Checkers[5].Name).To(Equal("status-healthy"))
+	g.Expect(resp.Checkers[5].Cluster).To(Equal("cluster-b"))
+	g.Expect(resp.Checkers[5].ErrorCode).To(Equal("OtherCode"))
+}
+
+// newScheduler returns a scheduler of the named cluster that is not started and holds the given checkers.
+func newScheduler(cluster string, chks ...checker.Checker) *scheduler.Scheduler {
+	var schedules []scheduler.CheckerSchedule
+	for _, chk := range chks {
+		schedules = append(schedules, scheduler.CheckerSchedule{Interval: time.Hour, Timeout: time.Second, Checker: chk})
+	}
+	s := scheduler.NewScheduler(schedules)
+	s.SetCluster(cluster)
+	return s
+}
+
+func TestNewHandler_MethodNotAllowed(t *testing.T) {
+	g := NewWithT(t)
+	handler := NewHandler(func() []*scheduler.Scheduler { return nil })
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, Path, nil))
+	g.Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+}
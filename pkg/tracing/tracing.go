@@ -0,0 +1,50 @@
+// Package tracing exports the traces of checker runs with OTLP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"k8s.io/klog/v2"
+)
+
+// serviceName is the service name of the exported spans.
+const serviceName = "cluster-health-monitor"
+
+// Config is the configuration of the trace export.
+type Config struct {
+	// Endpoint is the URL of the OTLP/HTTP traces endpoint of a collector, e.g. http://otel-collector.monitoring:4318/v1/traces. Traces
+	// are sent in plain text if the scheme is http.
+	Endpoint string
+	// SamplingRatio is the fraction of checker runs that are traced, between 0 and 1.
+	SamplingRatio float64
+}
+
+// Setup installs a global tracer provider that exports the spans of checker runs to the OTLP endpoint of the configuration. The returned
+// function flushes the pending spans and shuts the tracer provider down. Without Setup, spans are not recorded.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.SamplingRatio < 0 || cfg.SamplingRatio > 1 {
+		return nil, fmt.Errorf("invalid sampling ratio %v, it must be between 0 and 1", cfg.SamplingRatio)
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", serviceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SamplingRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	klog.InfoS("Exporting checker run traces", "endpoint", cfg.Endpoint, "samplingRatio", cfg.SamplingRatio)
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,302 @@
+// Package webhook implements the Webhook result sink, which POSTs a notification to the configured webhooks whenever the effective
+// status of a checker, or of a target of a per-target checker, changes.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	"k8s.io/klog/v2"
+)
+
+const (
+	defaultTimeout      = 10 * time.Second
+	defaultRetryBackoff = time.Second
+	// queueSize is the number of notifications queued per webhook. Notifications are dropped while the queue is full, e.g. while the
+	// webhook is unreachable and requests are retried.
+	queueSize = 100
+)
+
+// Notification is the payload of a webhook request. It is sent as JSON, or passed to the body template of the webhook.
+type Notification struct {
+	// Webhook is the name of the webhook the notification is sent to.
+	Webhook string `json:"webhook"`
+	// Cluster is the name of the cluster the checker checks. It is empty unless the monitor checks several clusters.
+	Cluster string `json:"cluster,omitempty"`
+	// CheckerName is the name of the checker whose effective status changed.
+	CheckerName string `json:"checkerName"`
+	// CheckerType is the type of the checker.
+	CheckerType string `json:"checkerType"`
+	// Target is the target whose effective status changed. It is empty for checker-level results.
+	Target string `json:"target,omitempty"`
+	// Status is the new effective status.
+	Status string `json:"status"`
+	// PreviousStatus is the effective status before the change. It is empty for the first result of the checker or target.
+	PreviousStatus string `json:"previousStatus,omitempty"`
+	// ErrorCode is the error code of the result that changed the effective status.
+	ErrorCode string `json:"errorCode"`
+	// Message is the detail message, or the error message, of the result that changed the effective status.
+	Message string `json:"message"`
+	// RunID is the unique ID of the run the result was recorded in.
+	RunID string `json:"runID,omitempty"`
+	// Timestamp is the time at which the result was recorded.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func Register() {
+	checker.RegisterResultSink(config.ResultSinkWebhook, BuildNotifier)
+}
+
+// BuildNotifier creates a notifier for the webhooks of the configuration. It starts one worker per webhook, which are stopped by Close.
+func BuildNotifier(cfg *config.Config) (checker.ResultSink, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{cancel: cancel}
+	for i := range cfg.Webhooks {
+		r, err := newReceiver(&cfg.Webhooks[i])
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		n.receivers = append(n.receivers, r)
+	}
+	for _, r := range n.receivers {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			r.run(ctx)
+		}()
+	}
+	return n, nil
+}
+
+// Notifier is a result sink that notifies webhooks of effective status changes. A notification is sent when the effective status differs
+// from the previous effective status, or for the first result of a checker or target unless it is healthy or skipped. Requests are sent in
+// the background, so Record never blocks on a webhook.
+type Notifier struct {
+	receivers []*receiver
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func (n *Notifier) Record(record checker.ResultRecord) {
+	if !isStatusChange(record) {
+		return
+	}
+	notification := Notification{
+		Cluster:        record.Cluster,
+		CheckerName:    record.CheckerName,
+		CheckerType:    string(record.CheckerType),
+		Target:         record.Target,
+		Status:         record.EffectiveStatus,
+		PreviousStatus: record.PreviousEffectiveStatus,
+		ErrorCode:      record.ErrorCode,
+		Message:        record.Message,
+		RunID:          record.RunID,
+		Timestamp:      record.Timestamp,
+	}
+	for _, r := range n.receivers {
+		r.enqueue(notification)
+	}
+}
+
+// Close stops the workers and waits for them to return. Queued notifications that have not been sent yet are discarded.
+func (n *Notifier) Close() {
+	n.cancel()
+	n.wg.Wait()
+}
+
+// isStatusChange returns whether a result changed the effective status of its checker or target. A pending effective status, i.e. a
+// first failing result below the failure threshold, is not a change.
+func isStatusChange(record checker.ResultRecord) bool {
+	if record.EffectiveStatus == "" {
+		return false
+	}
+	if record.PreviousEffectiveStatus == "" {
+		return record.EffectiveStatus != metrics.HealthyStatus && record.EffectiveStatus != metrics.SkippedStatus
+	}
+	return record.EffectiveStatus != record.PreviousEffectiveStatus
+}
+
+// dedupeKey identifies the checker or target whose notifications are deduplicated within the dedupe window of a webhook.
+type dedupeKey struct {
+	cluster     string
+	checkerName string
+	target      string
+}
+
+// sentNotification is the last notification queued for a checker or target.
+type sentNotification struct {
+	status    string
+	timestamp time.Time
+}
+
+// receiver sends the notifications of a single webhook.
+type receiver struct {
+	cfg      *config.WebhookConfig
+	template *template.Template
+	client   *http.Client
+	queue    chan Notification
+
+	mu   sync.Mutex
+	sent map[dedupeKey]sentNotification
+}
+
+func newReceiver(cfg *config.WebhookConfig) (*receiver, error) {
+	r := &receiver{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		queue:  make(chan Notification, queueSize),
+		sent:   make(map[dedupeKey]sentNotification),
+	}
+	if r.client.Timeout == 0 {
+		r.client.Timeout = defaultTimeout
+	}
+	if cfg.BodyTemplate != "" {
+		tmpl, err := template.New(cfg.Name).Funcs(config.WebhookTemplateFuncs).Parse(cfg.BodyTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse body template of webhook %q: %w", cfg.Name, err)
+		}
+		r.template = tmpl
+	}
+	return r, nil
+}
+
+// enqueue queues a notification unless the webhook is not notified about the checker, the notification is a duplicate within the dedupe
+// window or the queue is full.
+func (r *receiver) enqueue(n Notification) {
+	if len(r.cfg.Checkers) > 0 && !slices.Contains(r.cfg.Checkers, n.CheckerName) {
+		return
+	}
+	if r.isDuplicate(n) {
+		klog.V(3).InfoS("Skipped duplicate webhook notification", "webhook", r.cfg.Name, "cluster", n.Cluster, "name", n.CheckerName, "target", n.Target,
+			"status", n.Status)
+		return
+	}
+	n.Webhook = r.cfg.Name
+	select {
+	case r.queue <- n:
+	default:
+		metrics.WebhookNotificationCounter.WithLabelValues(n.Cluster, r.cfg.Name, metrics.WebhookDropped).Inc()
+		klog.ErrorS(nil, "Dropped webhook notification, the queue is full", "webhook", r.cfg.Name, "cluster", n.Cluster, "name", n.CheckerName,
+			"target", n.Target, "status", n.Status)
+	}
+}
+
+// isDuplicate returns whether the last notification queued for the checker or target within the dedupe window has the same status, and
+// remembers the notification otherwise. A status that changes back within the window is not a duplicate, so that the receiver always
+// knows the current status.
+func (r *receiver) isDuplicate(n Notification) bool {
+	if r.cfg.DedupeWindow == 0 {
+		return false
+	}
+	key := dedupeKey{cluster: n.Cluster, checkerName: n.CheckerName, target: n.Target}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, sent := range r.sent {
+		if n.Timestamp.Sub(sent.timestamp) >= r.cfg.DedupeWindow {
+			delete(r.sent, k)
+		}
+	}
+	if sent, ok := r.sent[key]; ok && sent.status == n.Status {
+		return true
+	}
+	r.sent[key] = sentNotification{status: n.Status, timestamp: n.Timestamp}
+	return false
+}
+
+// run sends queued notifications until ctx is done.
+func (r *receiver) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-r.queue:
+			if err := r.send(ctx, n); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				metrics.WebhookNotificationCounter.WithLabelValues(n.Cluster, r.cfg.Name, metrics.WebhookFailed).Inc()
+				klog.ErrorS(err, "Failed to send webhook notification", "webhook", r.cfg.Name, "cluster", n.Cluster, "name", n.CheckerName,
+					"target", n.Target, "status", n.Status)
+				continue
+			}
+			metrics.WebhookNotificationCounter.WithLabelValues(n.Cluster, r.cfg.Name, metrics.WebhookSent).Inc()
+			klog.V(3).InfoS("Sent webhook notification", "webhook", r.cfg.Name, "cluster", n.Cluster, "name", n.CheckerName, "target", n.Target,
+				"status", n.Status)
+		}
+	}
+}
+
+// send POSTs a notification to the webhook. Failed requests are retried up to MaxRetries times, doubling the backoff after every retry.
+func (r *receiver) send(ctx context.Context, n Notification) error {
+	body, err := r.render(n)
+	if err != nil {
+		return err
+	}
+	backoff := r.cfg.RetryBackoff
+	if backoff == 0 {
+		backoff = defaultRetryBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		retryable, err := r.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= r.cfg.MaxRetries {
+			return err
+		}
+		klog.V(2).InfoS("Retrying webhook notification", "webhook", r.cfg.Name, "attempt", attempt+1, "backoff", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// render returns the request body of a notification: the rendered body template, or the JSON encoding of the notification.
+func (r *receiver) render(n Notification) ([]byte, error) {
+	if r.template == nil {
+		return json.Marshal(n)
+	}
+	var buf bytes.Buffer
+	if err := r.template.Execute(&buf, n); err != nil {
+		return nil, fmt.Errorf("failed to render body template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// post sends a single request and returns whether it may be retried if it failed.
+func (r *receiver) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range r.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("unexpected response status: %s", resp.Status)
+}
@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/cluster-health-monitor/pkg/checker"
+	"github.com/Azure/cluster-health-monitor/pkg/config"
+	"github.com/Azure/cluster-health-monitor/pkg/metrics"
+	. "github.com/onsi/gomega"
+)
+
+// fakeReceiver is a local HTTP stand-in for a webhook that records the request bodies and answers with the queued status codes, and with
+// 200 once they are used up.
+type fakeReceiver struct {
+	mu       sync.Mutex
+	bodies   []string
+	headers  []http.Header
+	statuses []int
+}
+
+func (f *fakeReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.bodies = append(f.bodies, string(body))
+	f.headers = append(f.headers, r.Header.Clone())
+	status := http.StatusOK
+	if len(f.statuses) > 0 {
+		status, f.statuses = f.statuses[0], f.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (f *fakeReceiver) requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.bodies...)
+}
+
+func newNotifier(t *testing.T, webhooks ...config.WebhookConfig) *Notifier {
+	sink, err := BuildNotifier(&config.Config{Webhooks: webhooks})
+	if err != nil {
+		t.Fatalf("failed to build notifier: %v", err)
+	}
+	n := sink.(*Notifier)
+	t.Cleanup(n.Close)
+	return n
+}
+
+func record(checkerName, target, previous, effective string, timestamp time.Time) checker.ResultRecord {
+	return checker.ResultRecord{
+		CheckerName:             checkerName,
+		CheckerType:             config.CheckTypeDNS,
+		Target:                  target,
+		Status:                  effective,
+		ErrorCode:               "CODE",
+		Message:                 "message",
+		EffectiveStatus:         effective,
+		PreviousEffectiveStatus: previous,
+		Timestamp:               timestamp,
+	}
+}
+
+func TestNotifier_StatusChanges(t *testing.T) {
+	g := NewWithT(t)
+	fake := &fakeReceiver{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	n := newNotifier(t, config.WebhookConfig{Name: "json", URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+
+	now := time.Now()
+	n.Record(record("dns", "", "", metrics.HealthyStatus, now))
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.HealthyStatus, now))
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, now))
+	n.Record(record("dns", "coredns-1", "", metrics.UnknownStatus, now))
+	pending := record("dns", "coredns-2", "", "", now)
+	pending.Status = metrics.UnhealthyStatus
+	n.Record(pending)
+
+	g.Eventually(fake.requests).Should(HaveLen(2), "the first healthy result, pending and unchanged statuses are not notified")
+	g.Consistently(fake.requests, 100*time.Millisecond).Should(HaveLen(2))
+	var notification Notification
+	g.Expect(json.Unmarshal([]byte(fake.requests()[0]), &notification)).To(Succeed())
+	g.Expect(notification).To(Equal(Notification{
+		Webhook:        "json",
+		CheckerName:    "dns",
+		CheckerType:    string(config.CheckTypeDNS),
+		Status:         metrics.UnhealthyStatus,
+		PreviousStatus: metrics.HealthyStatus,
+		ErrorCode:      "CODE",
+		Message:        "message",
+		Timestamp:      notification.Timestamp,
+	}))
+	g.Expect(notification.Timestamp.Equal(now)).To(BeTrue())
+	g.Expect(json.Unmarshal([]byte(fake.requests()[1]), &notification)).To(Succeed())
+	g.Expect(notification.Target).To(Equal("coredns-1"))
+	g.Expect(fake.headers[0].Get("Content-Type")).To(Equal("application/json"))
+	g.Expect(fake.headers[0].Get("Authorization")).To(Equal("Bearer token"))
+}
+
+func TestNotifier_TemplateAndFilter(t *testing.T) {
+	g := NewWithT(t)
+	fake := &fakeReceiver{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	n := newNotifier(t, config.WebhookConfig{
+		Name:         "slack",
+		URL:          server.URL,
+		Checkers:     []string{"dns"},
+		BodyTemplate: `{"text": {{ json (printf "%s is %s: %s" .CheckerName .Status .Message) }}}`,
+	})
+
+	now := time.Now()
+	n.Record(record("apiserver", "", metrics.HealthyStatus, metrics.UnhealthyStatus, now))
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, now))
+
+	g.Eventually(fake.requests).Should(HaveLen(1))
+	g.Consistently(fake.requests, 100*time.Millisecond).Should(HaveLen(1), "the webhook is not notified about other checkers")
+	g.Expect(fake.requests()[0]).To(MatchJSON(`{"text": "dns is Unhealthy: message"}`))
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	g := NewWithT(t)
+	fake := &fakeReceiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusBadRequest}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	n := newNotifier(t, config.WebhookConfig{Name: "retry", URL: server.URL, MaxRetries: 2, RetryBackoff: 10 * time.Millisecond})
+
+	now := time.Now()
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, now))
+	g.Eventually(fake.requests).Should(HaveLen(3), "503 and 429 are retried, 400 is not")
+
+	n.Record(record("dns", "", metrics.UnhealthyStatus, metrics.HealthyStatus, now))
+	g.Eventually(fake.requests).Should(HaveLen(4))
+	g.Consistently(fake.requests, 100*time.Millisecond).Should(HaveLen(4))
+}
+
+func TestNotifier_DedupeWindow(t *testing.T) {
+	g := NewWithT(t)
+	fake := &fakeReceiver{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	n := newNotifier(t, config.WebhookConfig{Name: "dedupe", URL: server.URL, DedupeWindow: time.Minute})
+
+	start := time.Now()
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, start))
+	n.Record(record("dns", "", metrics.UnhealthyStatus, metrics.HealthyStatus, start.Add(10*time.Second)))
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, start.Add(20*time.Second)))
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, start.Add(30*time.Second)))
+	n.Record(record("dns", "coredns-1", metrics.HealthyStatus, metrics.UnhealthyStatus, start.Add(30*time.Second)))
+	n.Record(record("dns", "", metrics.HealthyStatus, metrics.UnhealthyStatus, start.Add(2*time.Minute)))
+
+	g.Eventually(fake.requests).Should(HaveLen(5))
+	g.Consistently(fake.requests, 100*time.Millisecond).Should(HaveLen(5),
+		"only the repeated unhealthy status within the window is deduplicated, the flip back, other targets and later changes are not")
+	var statuses []string
+	for _, body := range fake.requests() {
+		var notification Notification
+		g.Expect(json.Unmarshal([]byte(body), &notification)).To(Succeed())
+		statuses = append(statuses, notification.Status)
+	}
+	g.Expect(statuses).To(Equal([]string{
+		metrics.UnhealthyStatus, metrics.HealthyStatus, metrics.UnhealthyStatus, metrics.UnhealthyStatus, metrics.UnhealthyStatus,
+	}))
+}
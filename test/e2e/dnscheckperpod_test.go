@@ -36,7 +36,7 @@ var _ = Describe("DNS per pod checker metrics", Ordered, ContinueOnFailure, func
 	It("should report healthy status for CoreDNSPerPod checkers", func() {
 		By("Waiting for CoreDNSPerPod checker metrics to report healthy status")
 		Eventually(func() bool {
-			matched, foundCheckers := verifyCoreDNSPodCheckerResultMetrics(localPort, coreDNSPerPodCheckers, checkerTypeDNS, metricsHealthyStatus, metricsHealthyErrorCode)
+			matched, foundCheckers := verifyCheckerTargetResultMetrics(localPort, coreDNSPerPodCheckers, checkerTypeDNS, metricsHealthyStatus, metricsHealthyErrorCode)
 			if !matched {
 				GinkgoWriter.Printf("Expected CoreDNSPerPod checkers to be healthy: %v, found: %v\n", coreDNSPerPodCheckers, foundCheckers)
 				return false
@@ -49,7 +49,7 @@ var _ = Describe("DNS per pod checker metrics", Ordered, ContinueOnFailure, func
 	It("should report unhealthy status for CoreDNSPerPod checkers with minimal query timeout", func() {
 		By("Waiting for CoreDNSPerPod checker metrics to report unhealthy status")
 		Eventually(func() bool {
-			matched, foundCheckers := verifyCoreDNSPodCheckerResultMetrics(localPort, coreDNSPerPodCheckersWithMinimalTimeout, checkerTypeDNS, metricsUnhealthyStatus, podTimeoutErrorCode)
+			matched, foundCheckers := verifyCheckerTargetResultMetrics(localPort, coreDNSPerPodCheckersWithMinimalTimeout, checkerTypeDNS, metricsUnhealthyStatus, podTimeoutErrorCode)
 			if !matched {
 				GinkgoWriter.Printf("Expected CoreDNSPerPod checkers to be unhealthy: %v, found: %v\n", coreDNSPerPodCheckersWithMinimalTimeout, foundCheckers)
 				return false
@@ -40,12 +40,12 @@ const (
 	remoteMetricsPort = 9800  // remoteMetricsPort is the fixed port used by the service in the container.
 	baseLocalPort     = 10000 // baseLocalPort is the base local port for dynamic allocation.
 
-	checkerResultMetricName    = "cluster_health_monitor_checker_result_total"
-	coreDNSPodResultMetricName = "cluster_health_monitor_coredns_pod_result_total"
-	metricsCheckerTypeLabel    = "checker_type"
-	metricsCheckerNameLabel    = "checker_name"
-	metricsStatusLabel         = "status"
-	metricsErrorCodeLabel      = "error_code"
+	checkerResultMetricName       = "cluster_health_monitor_checker_result_total"
+	checkerTargetResultMetricName = "cluster_health_monitor_checker_target_result_total"
+	metricsCheckerTypeLabel       = "checker_type"
+	metricsCheckerNameLabel       = "checker_name"
+	metricsStatusLabel            = "status"
+	metricsErrorCodeLabel         = "error_code"
 )
 
 // safeSessionKill is shorthand to kill the provided gexec.Session if it is not nil.
@@ -438,8 +438,8 @@ func disableMockLocalDNS(dynamicClient dynamic.Interface) error {
 	return nil
 }
 
-func verifyCoreDNSPodCheckerResultMetrics(localPort int, expectedChkNames []string, expectedType, expectedStatus, expectedErrorCode string) (bool, map[string]struct{}) {
-	return verifyCheckerResultMetricsHelper(coreDNSPodResultMetricName, localPort, expectedChkNames, expectedType, expectedStatus, expectedErrorCode, []string{"pod_name"})
+func verifyCheckerTargetResultMetrics(localPort int, expectedChkNames []string, expectedType, expectedStatus, expectedErrorCode string) (bool, map[string]struct{}) {
+	return verifyCheckerResultMetricsHelper(checkerTargetResultMetricName, localPort, expectedChkNames, expectedType, expectedStatus, expectedErrorCode, []string{"target"})
 }
 
 func verifyCheckerResultMetrics(localPort int, expectedChkNames []string, expectedType, expectedStatus, expectedErrorCode string) (bool, map[string]struct{}) {